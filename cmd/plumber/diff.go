@@ -0,0 +1,241 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+)
+
+// diffContext is the number of unchanged lines around each hunk of a diff.
+const diffContext = 3
+
+// writeDiff applies the suggested fixes of results to each file in memory, and writes a
+// unified diff (like that of diff -u) of the changes to w, in order of the file names.
+// Paths are relative to dir (see relPath).
+func writeDiff(w io.Writer, dir string, results []ctxtodo.Result) error {
+	edits := map[string][]ctxtodo.Edit{}
+	for _, res := range results {
+		for _, fix := range res.Fixes {
+			for _, edit := range fix.Edits {
+				edits[edit.Filename] = append(edits[edit.Filename], edit)
+			}
+		}
+	}
+	var files []string
+	for file := range edits {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		before, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		after, err := applyEdits(before, edits[file])
+		if err != nil {
+			return fmt.Errorf("%s: %s", file, err)
+		}
+		name := relPath(dir, file)
+		fmt.Fprintf(w, "--- a/%s\n+++ b/%s\n", name, name)
+		if err := writeHunks(w, splitLines(string(before)), splitLines(string(after))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyEdits returns src with edits applied.  The same edit can be suggested by more
+// than one fix (like the import of context), so duplicates are only applied once.
+func applyEdits(src []byte, edits []ctxtodo.Edit) ([]byte, error) {
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].Offset != edits[j].Offset {
+			return edits[i].Offset < edits[j].Offset
+		}
+		return edits[i].End < edits[j].End // an insertion goes before a replacement at the same offset
+	})
+	var out []byte
+	last := ctxtodo.Edit{}
+	for _, edit := range edits {
+		if edit == last {
+			continue
+		}
+		if edit.Offset < last.End || edit.End > len(src) {
+			return nil, fmt.Errorf("conflicting edits at offsets %d and %d", last.Offset, edit.Offset)
+		}
+		out = append(out, src[last.End:edit.Offset]...)
+		out = append(out, edit.NewText...)
+		last = edit
+	}
+	return append(out, src[last.End:]...), nil
+}
+
+// splitLines splits s into lines, each of which keeps its newline (if it has one).
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// An op is a line of a diff: an unchanged line (' '), or one that was deleted ('-') or inserted ('+').
+type op struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the shortest edit script that turns a into b, found with
+// Myers' algorithm ("An O(ND) Difference Algorithm and Its Variations").
+func diffLines(a, b []string) []op {
+	n, m := len(a), len(b)
+	max := n + m
+	v := make([]int, 2*max+2) // v[k+max] is the furthest x reached on diagonal k
+	var trace [][]int
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[k-1+max] < v[k+1+max] {
+				x = v[k+1+max] // down: insert b[y]
+			} else {
+				x = v[k-1+max] + 1 // right: delete a[x]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[k+max] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk back through the trace to recover the edits, in reverse
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || k != d && v[k-1+max] < v[k+1+max] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+max]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			ops = append(ops, op{' ', a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{'+', b[y]})
+		} else {
+			x--
+			ops = append(ops, op{'-', a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		ops = append(ops, op{' ', a[x]})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// writeHunks writes the differences between a and b to w as the hunks of a unified diff,
+// each with diffContext lines of context.
+func writeHunks(w io.Writer, a, b []string) error {
+	ops := diffLines(a, b)
+	for start := 0; start < len(ops); {
+		// Find the next change, and the end of the hunk containing it
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		end := start
+		for i := start; i < len(ops) && i-end <= 2*diffContext; i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			}
+		}
+		first, last := start-diffContext, end+diffContext
+		if first < 0 {
+			first = 0
+		}
+		if last > len(ops) {
+			last = len(ops)
+		}
+
+		// The hunk header counts the lines of a and b before and within it
+		var aLine, bLine, aLen, bLen int
+		for _, o := range ops[:first] {
+			if o.kind != '+' {
+				aLine++
+			}
+			if o.kind != '-' {
+				bLine++
+			}
+		}
+		for _, o := range ops[first:last] {
+			if o.kind != '+' {
+				aLen++
+			}
+			if o.kind != '-' {
+				bLen++
+			}
+		}
+		if _, err := fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(aLine, aLen), hunkRange(bLine, bLen)); err != nil {
+			return err
+		}
+		for _, o := range ops[first:last] {
+			line := o.line
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n\\ No newline at end of file\n"
+			}
+			if _, err := fmt.Fprintf(w, "%c%s", o.kind, line); err != nil {
+				return err
+			}
+		}
+		start = last
+	}
+	return nil
+}
+
+// hunkRange formats the range of a hunk that starts after the first lines of a file.
+func hunkRange(before, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", before) // the line after which it is inserted
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, n)
+}
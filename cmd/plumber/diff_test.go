@@ -0,0 +1,95 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+)
+
+func TestWriteDiff(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	mod := basicModule(t)
+	want, err := os.ReadFile(filepath.Join("testdata", "basic.diff"))
+	if err != nil {
+		t.Fatalf("reading golden: %s", err)
+	}
+
+	results, err := ctxtodo.AnalyzePatterns(mod, []string{"./..."})
+	if err != nil {
+		t.Fatalf("AnalyzePatterns: %s", err)
+	}
+	var got bytes.Buffer
+	if err := writeDiff(&got, mod, results); err != nil {
+		t.Fatalf("writeDiff: %s", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("diff:\n%s\nwant:\n%s", got.Bytes(), want)
+	}
+}
+
+// basicModule copies the basic testdata into a module of its own to run against,
+// returning its directory.
+func basicModule(t *testing.T) string {
+	t.Helper()
+
+	testdata := filepath.Join("..", "..", "ctxtodo", "testdata", "src", "basic")
+	src, err := os.ReadFile(filepath.Join(testdata, "basic1.go"))
+	if err != nil {
+		t.Fatalf("reading testdata: %s", err)
+	}
+	mod := filepath.Join(t.TempDir(), "basic")
+	if err := os.Mkdir(mod, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mod, "go.mod"), []byte("module basic\n\ngo 1.16\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mod, "basic1.go"), src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return mod
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string // the kinds of the ops
+	}{
+		{"", "", ""},
+		{"a\nb\n", "a\nb\n", "  "},
+		{"a\nb\n", "a\nx\nb\n", " + "},
+		{"a\nb\nc\n", "a\nc\n", " - "},
+		{"a\nb\nc\n", "a\nx\nc\n", " -+ "},
+		{"", "a\n", "+"},
+		{"a\n", "", "-"},
+	}
+	for _, test := range tests {
+		var got []byte
+		for _, o := range diffLines(splitLines(test.a), splitLines(test.b)) {
+			got = append(got, o.kind)
+		}
+		if string(got) != test.want {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", test.a, test.b, got, test.want)
+		}
+	}
+}
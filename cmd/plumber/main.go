@@ -0,0 +1,90 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command plumber plumbs context.Context values through multiple layers of calls.
+//
+// Usage:
+//
+//	plumber [flags] packages...
+//
+// In addition to the standard analysis flags (like -fix), it accepts the
+// flags of the ctxtodo analyzer (like -modcache and -maxdepth); run
+// plumber -help for the full list.  Flags can also be set in a .plumber.yaml
+// file in the root of the module; those given on the command line take precedence.
+//
+// The -report-format flag chooses how diagnostics are reported: "text" (the default),
+// "json" (a line of JSON for each, like -json-diagnostics), "github" (workflow commands
+// that GitHub Actions shows as annotations on the lines of a pull request), "diff"
+// (a unified diff of the changes that -fix would make, for review), or "sarif" (a SARIF 2.1.0
+// log for static analysis aggregators, with paths relative to the root of the module).
+// The standard analysis flags (like -fix) are only available with "text".
+//
+// With -validate, plumber applies the suggested fixes like -fix, after checking that the
+// packages still type-check with them: the files of those that don't are left alone,
+// and their type errors are reported along with the diagnostics whose fixes caused them.
+//
+// As with other analysis drivers, plumber exits with a non-zero status when
+// it reports any diagnostics.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+	"github.com/kylelemons/plumber/internal/config"
+)
+
+var (
+	reportFormat = flag.String("report-format", "text", "How to report diagnostics: text, json, github, diff, or sarif")
+	validate     = flag.Bool("validate", false, "Apply the suggested fixes (like -fix), but only to the packages that still type-check with them")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("plumber: ")
+
+	if path, ok := config.Find("."); ok {
+		if err := config.Load(path, &ctxtodo.Analyzer.Flags); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// The format has to be known before the flags are parsed, since singlechecker
+	// parses them itself (and registers the standard analysis flags).
+	format := *reportFormat
+	if arg, ok := flagArg(os.Args[1:], "report-format", false); ok {
+		format = arg
+	}
+	if arg, ok := flagArg(os.Args[1:], "validate", true); ok {
+		if v, err := strconv.ParseBool(arg); err == nil && v {
+			if format != "text" {
+				log.Fatalf("-validate can't be used with -report-format=%s", format)
+			}
+			os.Exit(fixValidated())
+		}
+	}
+	switch format {
+	case "text":
+		singlechecker.Main(ctxtodo.Analyzer)
+	case "json", "github", "diff", "sarif":
+		os.Exit(report(format))
+	default:
+		log.Fatalf("unknown -report-format %q (want text, json, github, diff, or sarif)", format)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+)
+
+// demoModule builds plumber and copies the demo testdata into a module of its own,
+// returning a function that runs plumber in it (without a cache) and the path of its main.go.
+func demoModule(t *testing.T) (run func(args ...string) ([]byte, error), mainGo string) {
+	t.Helper()
+
+	tmp := t.TempDir()
+	plumber := filepath.Join(tmp, "plumber")
+	if out, err := exec.Command("go", "build", "-o", plumber, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %s\n%s", err, out)
+	}
+
+	src, err := os.ReadFile(filepath.Join(demoTestdata, "main.go"))
+	if err != nil {
+		t.Fatalf("reading testdata: %s", err)
+	}
+	mod := filepath.Join(tmp, "demo")
+	if err := os.Mkdir(mod, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mod, "go.mod"), []byte("module demo\n\ngo 1.16\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mod, "main.go"), src, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run = func(args ...string) ([]byte, error) {
+		cmd := exec.Command(plumber, append([]string{"-cache-dir="}, args...)...)
+		cmd.Dir = mod
+		cmd.Env = append(os.Environ(), "GOWORK=off")
+		return cmd.CombinedOutput()
+	}
+	return run, filepath.Join(mod, "main.go")
+}
+
+func TestMain(m *testing.M) {
+	// Keep the tests that analyze packages themselves out of the real cache
+	ctxtodo.CacheDir = ""
+	os.Exit(m.Run())
+}
+
+var demoTestdata = filepath.Join("..", "..", "ctxtodo", "testdata", "src", "demo")
+
+func TestPlumberFix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	want, err := os.ReadFile(filepath.Join(demoTestdata, "main.go.golden"))
+	if err != nil {
+		t.Fatalf("reading golden: %s", err)
+	}
+	run, mainGo := demoModule(t)
+
+	out, err := run("./...")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("plumber = %v, want non-zero exit for reported diagnostics\n%s", err, out)
+	}
+	if !bytes.Contains(out, []byte("Plumb context")) {
+		t.Errorf("plumber output is missing diagnostics:\n%s", out)
+	}
+
+	if out, err = run("-fix", "./..."); err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("plumber -fix: %s\n%s", err, out)
+	}
+
+	got, err := os.ReadFile(mainGo)
+	if err != nil {
+		t.Fatalf("reading fixed output: %s", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("plumber -fix output does not match golden file\n--- got:\n%s\n--- want:\n%s\n--- output:\n%s", got, want, out)
+	}
+}
+
+func TestReportFormat(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	run, mainGo := demoModule(t)
+	tests := []struct {
+		format string
+		want   string // the golden file in testdata, with $MAIN for the path of main.go
+	}{
+		{"text", "demo.txt"},
+		{"json", "demo.json"},
+		{"github", "demo.github"},
+		{"diff", "demo.diff"},
+		{"sarif", "demo.sarif"},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", test.want))
+			if err != nil {
+				t.Fatalf("reading golden: %s", err)
+			}
+			want = bytes.ReplaceAll(want, []byte("$MAIN"), []byte(mainGo))
+
+			out, err := run("-report-format="+test.format, "./...")
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+				t.Fatalf("plumber = %v, want exit status 3 for reported diagnostics\n%s", err, out)
+			}
+			if !bytes.Equal(out, want) {
+				t.Errorf("plumber -report-format=%s output:\n%s\nwant:\n%s", test.format, out, want)
+			}
+		})
+	}
+}
+
+// TestDiffDryRun ensures that the diff still previews the fixes with -dry-run,
+// which drops them from the other formats, after the plan.
+func TestDiffDryRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	want, err := os.ReadFile(filepath.Join("testdata", "demo.diff"))
+	if err != nil {
+		t.Fatalf("reading golden: %s", err)
+	}
+	run, _ := demoModule(t)
+
+	out, err := run("-report-format=diff", "-dry-run", "./...")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("plumber = %v, want exit status 3 for reported diagnostics\n%s", err, out)
+	}
+	i := bytes.Index(out, []byte("--- a/"))
+	if i < 0 || !bytes.Equal(out[i:], want) {
+		t.Errorf("plumber -report-format=diff -dry-run diff:\n%s\nwant:\n%s", out, want)
+	}
+	if i < 0 || !bytes.Contains(out[:i], []byte("add ctx parameter to fetch")) {
+		t.Errorf("plumber -report-format=diff -dry-run is missing the plan:\n%s", out)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+)
+
+// flagArg returns the last value of the flag with the given name in args, without parsing
+// the rest of them.  A boolean flag given without a value (like -validate) is "true".
+func flagArg(args []string, name string, isBool bool) (value string, ok bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		switch flag := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-"); {
+		case flag == name && isBool:
+			value, ok = "true", true
+		case flag == name && i+1 < len(args):
+			value, ok = args[i+1], true
+		case strings.HasPrefix(flag, name+"="):
+			value, ok = strings.TrimPrefix(flag, name+"="), true
+		}
+	}
+	return value, ok
+}
+
+// analyzeArgs parses the command line (with the flags of the analyzer, rather than those
+// of singlechecker) and analyzes the packages given on it.  If it fails, the exit status
+// is non-zero.
+func analyzeArgs(usage string) (results []ctxtodo.Result, status int) {
+	ctxtodo.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		flag.Var(f.Value, f.Name, f.Usage)
+	})
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s\n\nFlags:\n", usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		return nil, 2
+	}
+
+	results, err := ctxtodo.AnalyzePatterns("", flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	return results, 0
+}
+
+// dropFixes removes the fixes from results for -dry-run, which (like the analyzer)
+// only reports the plan of their edits.
+func dropFixes(results []ctxtodo.Result) {
+	for i := range results {
+		results[i].Fixes = nil
+	}
+}
+
+// report analyzes the packages given on the command line and writes their diagnostics
+// to stdout in format, returning the exit status: like singlechecker, it is 3 if any
+// diagnostics were reported.
+func report(format string) int {
+	results, status := analyzeArgs("plumber -report-format=" + format + " [flags] packages...")
+	if status != 0 {
+		return status
+	}
+	if ctxtodo.DryRun && format != "diff" { // the diff previews the fixes, after the plan
+		dropFixes(results)
+	}
+
+	var err error
+	switch format {
+	case "json":
+		err = ctxtodo.WriteJSON(os.Stdout, results)
+	case "github":
+		dir, _ := os.Getwd()
+		err = writeGitHub(os.Stdout, dir, results)
+	case "diff":
+		dir, _ := os.Getwd()
+		err = writeDiff(os.Stdout, dir, results)
+	case "sarif":
+		err = writeSARIF(os.Stdout, results)
+	}
+	if err != nil {
+		log.Fatalf("writing diagnostics: %s", err)
+	}
+	if len(results) > 0 {
+		return 3
+	}
+	return 0
+}
+
+// writeGitHub writes each of results to w as a GitHub Actions workflow command,
+// which is shown as an annotation on its lines.  Paths are relative to dir (see relPath).
+func writeGitHub(w io.Writer, dir string, results []ctxtodo.Result) error {
+	for _, res := range results {
+		props := []string{
+			"file=" + escapeProperty(relPath(dir, res.Pos.Filename)),
+			fmt.Sprintf("line=%d", res.Pos.Line),
+			fmt.Sprintf("col=%d", res.Pos.Column),
+			fmt.Sprintf("endLine=%d", res.End.Line),
+			fmt.Sprintf("endColumn=%d", res.End.Column),
+		}
+		if res.Category != "" {
+			props = append(props, "title="+escapeProperty(res.Category))
+		}
+		if _, err := fmt.Fprintf(w, "::warning %s::%s\n", strings.Join(props, ","), escapeData(res.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relPath returns the slash-separated path of file relative to dir (the working directory,
+// which is usually the root of the repository), unless it is outside of it.
+func relPath(dir, file string) string {
+	if rel, err := filepath.Rel(dir, file); err == nil && !isOutside(rel) {
+		file = rel
+	}
+	return filepath.ToSlash(file)
+}
+
+// escapeData escapes s for the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes s for the value of a property of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
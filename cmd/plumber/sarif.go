@@ -0,0 +1,216 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+)
+
+// The subset of the SARIF 2.1.0 schema that is used to report diagnostics.
+//
+// Regions of results use line and column numbers, whose columns are byte-based
+// like those of go/token; regions of fixes use byte offsets, so they are exact.
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		Name             string       `json:"name"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+		Fixes     []sarifFix      `json:"fixes,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           sarifRegion           `json:"region"`
+	}
+	sarifArtifactLocation struct {
+		URI       string `json:"uri"`
+		URIBaseID string `json:"uriBaseId,omitempty"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+		EndLine     int `json:"endLine"`
+		EndColumn   int `json:"endColumn"`
+	}
+	sarifFix struct {
+		Description     sarifMessage          `json:"description"`
+		ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+	}
+	sarifArtifactChange struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Replacements     []sarifReplacement    `json:"replacements"`
+	}
+	sarifReplacement struct {
+		DeletedRegion   sarifByteRegion `json:"deletedRegion"`
+		InsertedContent *sarifMessage   `json:"insertedContent,omitempty"`
+	}
+	sarifByteRegion struct {
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+	}
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// srcRoot is the base of all artifact locations, which are relative to the module root.
+	srcRoot = "%SRCROOT%"
+)
+
+// sarifRules describes each of the categories of diagnostics as a rule.
+var sarifRules = []sarifRule{
+	{ctxtodo.CategoryPlumb, "plumb", sarifMessage{"A context can be plumbed to this context.TODO() call."}},
+	{ctxtodo.CategorySubstitute, "substitute", sarifMessage{"A context that is available can replace this call."}},
+	{ctxtodo.CategoryTransitive, "transitive", sarifMessage{"A function in another package gained a ctx parameter."}},
+	{ctxtodo.CategoryManual, "manual", sarifMessage{"The context must be plumbed here manually."}},
+	{ctxtodo.CategoryAudit, "audit", sarifMessage{"A context.TODO() call found by -only-todos."}},
+}
+
+// writeSARIF writes the diagnostics in results to w as a SARIF log.
+func writeSARIF(w io.Writer, results []ctxtodo.Result) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "plumber",
+				InformationURI: "https://github.com/kylelemons/plumber",
+				Rules:          sarifRules,
+			},
+		},
+		Results: []sarifResult{},
+	}
+	for _, result := range results {
+		run.Results = append(run.Results, sarifResultFor(result))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	})
+}
+
+func sarifResultFor(res ctxtodo.Result) sarifResult {
+	result := sarifResult{
+		RuleID:  res.Category,
+		Level:   "warning",
+		Message: sarifMessage{res.Message},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: artifactLocation(res.Package, res.Pos.Filename),
+				Region: sarifRegion{
+					StartLine:   res.Pos.Line,
+					StartColumn: res.Pos.Column,
+					EndLine:     res.End.Line,
+					EndColumn:   res.End.Column,
+				},
+			},
+		}},
+	}
+	for _, fix := range res.Fixes {
+		result.Fixes = append(result.Fixes, sarifFixFor(res.Package, fix))
+	}
+	return result
+}
+
+func sarifFixFor(pkg *packages.Package, fix ctxtodo.Fix) sarifFix {
+	var files []string
+	replacements := map[string][]sarifReplacement{}
+	for _, edit := range fix.Edits {
+		if _, ok := replacements[edit.Filename]; !ok {
+			files = append(files, edit.Filename)
+		}
+		repl := sarifReplacement{
+			DeletedRegion: sarifByteRegion{
+				ByteOffset: edit.Offset,
+				ByteLength: edit.End - edit.Offset,
+			},
+		}
+		if len(edit.NewText) > 0 {
+			repl.InsertedContent = &sarifMessage{edit.NewText}
+		}
+		replacements[edit.Filename] = append(replacements[edit.Filename], repl)
+	}
+
+	out := sarifFix{Description: sarifMessage{fix.Message}}
+	for _, filename := range files {
+		repls := replacements[filename]
+		sort.SliceStable(repls, func(i, j int) bool {
+			if repls[i].DeletedRegion.ByteOffset != repls[j].DeletedRegion.ByteOffset {
+				return repls[i].DeletedRegion.ByteOffset < repls[j].DeletedRegion.ByteOffset
+			}
+			return repls[i].DeletedRegion.ByteLength < repls[j].DeletedRegion.ByteLength
+		})
+		out.ArtifactChanges = append(out.ArtifactChanges, sarifArtifactChange{
+			ArtifactLocation: artifactLocation(pkg, filename),
+			Replacements:     repls,
+		})
+	}
+	return out
+}
+
+// artifactLocation returns the location of filename relative to the root of the module
+// containing pkg, falling back to the full path if it isn't in the module.
+func artifactLocation(pkg *packages.Package, filename string) sarifArtifactLocation {
+	if pkg.Module != nil {
+		if rel, err := filepath.Rel(pkg.Module.Dir, filename); err == nil && !isOutside(rel) {
+			return sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: srcRoot}
+		}
+	}
+	return sarifArtifactLocation{URI: filepath.ToSlash(filename)}
+}
+
+func isOutside(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
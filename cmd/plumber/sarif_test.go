@@ -0,0 +1,48 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	mod := basicModule(t)
+	want, err := os.ReadFile(filepath.Join("testdata", "basic.sarif"))
+	if err != nil {
+		t.Fatalf("reading golden: %s", err)
+	}
+
+	results, err := ctxtodo.AnalyzePatterns(mod, []string{"./..."})
+	if err != nil {
+		t.Fatalf("AnalyzePatterns: %s", err)
+	}
+	var got bytes.Buffer
+	if err := writeSARIF(&got, results); err != nil {
+		t.Fatalf("writeSARIF: %s", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("SARIF output:\n%s\nwant:\n%s", got.Bytes(), want)
+	}
+}
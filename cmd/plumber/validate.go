@@ -0,0 +1,346 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+)
+
+// fixValidated analyzes the packages given on the command line, reports their diagnostics
+// (like singlechecker), and applies their fixes to the packages that type-check with them.
+// It returns the exit status: 1 if the fixes of any package were rejected, or else 3
+// if any diagnostics were reported.
+func fixValidated() int {
+	results, status := analyzeArgs("plumber -validate [flags] packages...")
+	if status != 0 {
+		return status
+	}
+	if ctxtodo.DryRun {
+		dropFixes(results)
+	}
+	for _, res := range results {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", res.Pos, res.Message)
+	}
+
+	fixed, rejected, err := checkFixes("", flag.Args(), results)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, rej := range rejected {
+		if rej.with != nil {
+			log.Printf("not fixing %s, it is checked along with %s", rej.pkg.ID, rej.with.ID)
+			continue
+		}
+		log.Printf("not fixing %s, it doesn't type-check with the fixes:", rej.pkg.ID)
+		for _, e := range rej.errors {
+			log.Printf("\t%s", e)
+		}
+	}
+	for _, filename := range sortedKeys(fixed) {
+		info, err := os.Stat(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(filename, fixed[filename], info.Mode()); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	switch {
+	case len(rejected) > 0:
+		return 1
+	case len(results) > 0:
+		return 3
+	}
+	return 0
+}
+
+// A rejection is a package whose fixes were rejected, with its type errors, or else the
+// rejected package it is checked along with.
+type rejection struct {
+	pkg    *packages.Package
+	errors []string
+	with   *packages.Package
+}
+
+// An originEdit is an edit of a fix, along with the diagnostic it fixes.
+type originEdit struct {
+	ctxtodo.Edit
+	origin *ctxtodo.Result
+}
+
+// checkFixes applies the fixes of results to their files in memory, and type-checks the
+// packages matching patterns in dir (and their tests) that they edit or that import the
+// edited packages, in dependency order.  These include the packages without diagnostics,
+// like the tests calling a function that gains a ctx parameter.
+//
+// It returns the contents of the fixed files of the packages that type-check with the
+// fixes, and rejections for the others.  The packages that a rejected one imports or
+// shares files with (and so on) are rejected along with it, so that the files written
+// still type-check together.  Each type error is reported along with the diagnostic
+// whose fix made the nearest edit before it, which is likely the one that caused it.
+func checkFixes(dir string, patterns []string, results []ctxtodo.Result) (fixed map[string][]byte, rejected []rejection, err error) {
+	edits := map[string][]originEdit{}
+	for i := range results {
+		res := &results[i]
+		for _, fix := range res.Fixes {
+			for _, edit := range fix.Edits {
+				edits[edit.Filename] = append(edits[edit.Filename], originEdit{edit, res})
+			}
+		}
+	}
+
+	contents := map[string][]byte{}
+	for filename, fileEdits := range edits {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		sort.SliceStable(fileEdits, func(i, j int) bool {
+			if fileEdits[i].Offset != fileEdits[j].Offset {
+				return fileEdits[i].Offset < fileEdits[j].Offset
+			}
+			return fileEdits[i].End < fileEdits[j].End // in the order applyEdits applies them
+		})
+		plain := make([]ctxtodo.Edit, len(fileEdits))
+		for i, edit := range fileEdits {
+			plain[i] = edit.Edit
+		}
+		if contents[filename], err = applyEdits(src, plain); err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", filename, err)
+		}
+	}
+	if len(contents) == 0 {
+		return map[string][]byte{}, nil, nil
+	}
+
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesSizes,
+		Dir:   dir,
+		Tests: true,
+	}
+	roots, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n := packages.PrintErrors(roots); n > 0 {
+		return nil, nil, fmt.Errorf("%d errors loading packages to check", n)
+	}
+
+	var accepted []*packages.Package
+	checked := map[string]*types.Package{} // by ID, for the packages whose fixes were accepted
+	packages.Visit(roots, nil, func(pkg *packages.Package) {
+		if !needsCheck(pkg, contents, checked) {
+			return
+		}
+		typesPkg, typeErrs := typeCheck(pkg, contents, checked)
+		if len(typeErrs) == 0 {
+			checked[pkg.ID] = typesPkg
+			accepted = append(accepted, pkg)
+			return
+		}
+		rej := rejection{pkg: pkg}
+		for _, typeErr := range typeErrs {
+			msg := typeErr.Error()
+			var pos token.Position
+			switch typeErr := typeErr.(type) {
+			case types.Error:
+				pos = typeErr.Fset.Position(typeErr.Pos)
+			case *scanner.Error:
+				pos = typeErr.Pos
+			}
+			if origin := originAt(edits[pos.Filename], pos.Offset); origin != nil {
+				msg += fmt.Sprintf(" (from the fix for %s: %s)", origin.Pos, origin.Message)
+			}
+			rej.errors = append(rej.errors, msg)
+		}
+		rejected = append(rejected, rej)
+	})
+
+	// Reject the accepted packages connected to a rejected one, until there are no more.
+	// Only those with fixed files are reported.
+	for changed := true; changed; {
+		changed = false
+		for i := 0; i < len(accepted); i++ {
+			pkg := accepted[i]
+			with := connectedRejection(pkg, rejected)
+			if with == nil {
+				continue
+			}
+			rejected = append(rejected, rejection{pkg: pkg, with: with})
+			accepted = append(accepted[:i], accepted[i+1:]...)
+			i--
+			changed = true
+		}
+	}
+	reported := rejected[:0]
+	for _, rej := range rejected {
+		if rej.with == nil || hasFixedFiles(rej.pkg, contents) {
+			reported = append(reported, rej)
+		}
+	}
+
+	fixed = map[string][]byte{}
+	for _, pkg := range accepted {
+		for _, filename := range pkg.CompiledGoFiles {
+			if src, ok := contents[filename]; ok {
+				fixed[filename] = src
+			}
+		}
+	}
+	return fixed, reported, nil
+}
+
+// connectedRejection returns the rejected package that pkg imports, is imported by, or
+// shares a file with, if there is one.
+func connectedRejection(pkg *packages.Package, rejected []rejection) *packages.Package {
+	files := map[string]bool{}
+	for _, filename := range pkg.CompiledGoFiles {
+		files[filename] = true
+	}
+	for _, rej := range rejected {
+		for _, imp := range pkg.Imports {
+			if imp.ID == rej.pkg.ID {
+				return rej.pkg
+			}
+		}
+		for _, imp := range rej.pkg.Imports {
+			if imp.ID == pkg.ID {
+				return rej.pkg
+			}
+		}
+		for _, filename := range rej.pkg.CompiledGoFiles {
+			if files[filename] {
+				return rej.pkg
+			}
+		}
+	}
+	return nil
+}
+
+// hasFixedFiles reports whether any of the files of pkg were fixed.
+func hasFixedFiles(pkg *packages.Package, contents map[string][]byte) bool {
+	for _, filename := range pkg.CompiledGoFiles {
+		if _, ok := contents[filename]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// needsCheck reports whether pkg has to be type-checked again: if any of its files
+// were fixed, or if any of its imports were.
+func needsCheck(pkg *packages.Package, contents map[string][]byte, checked map[string]*types.Package) bool {
+	if hasFixedFiles(pkg, contents) {
+		return true
+	}
+	for _, imp := range pkg.Imports {
+		if _, ok := checked[imp.PkgPath]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// typeCheck parses and type-checks the files of pkg, using the fixed contents of those
+// that have them, and the checked versions of its imports that have been.  It returns
+// the syntax or type errors, if there are any.
+func typeCheck(pkg *packages.Package, contents map[string][]byte, checked map[string]*types.Package) (*types.Package, []error) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	var parseErrs []error
+	for _, filename := range pkg.CompiledGoFiles {
+		var src interface{}
+		if fixed, ok := contents[filename]; ok {
+			src = fixed
+		}
+		file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if list, ok := err.(scanner.ErrorList); ok {
+			for _, err := range list {
+				parseErrs = append(parseErrs, err)
+			}
+		} else if err != nil {
+			parseErrs = append(parseErrs, err)
+		}
+		files = append(files, file)
+	}
+	if len(parseErrs) > 0 {
+		return nil, parseErrs
+	}
+
+	var typeErrs []error
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			imp, ok := pkg.Imports[path]
+			if !ok {
+				return nil, fmt.Errorf("%s is not imported by %s", path, pkg.PkgPath)
+			}
+			if checked, ok := checked[imp.ID]; ok {
+				return checked, nil
+			}
+			return imp.Types, nil
+		}),
+		Sizes: pkg.TypesSizes,
+		Error: func(err error) { typeErrs = append(typeErrs, err) },
+	}
+	typesPkg, _ := conf.Check(pkg.PkgPath, fset, files, nil)
+	return typesPkg, typeErrs
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// originAt returns the diagnostic whose fix made the last of edits (which are sorted)
+// that starts at or before offset in the fixed file.
+func originAt(edits []originEdit, offset int) *ctxtodo.Result {
+	var origin *ctxtodo.Result
+	delta := 0 // from offsets in the original file to those in the fixed one
+	last := ctxtodo.Edit{}
+	for _, edit := range edits {
+		if edit.Edit == last {
+			continue // applied once
+		}
+		if edit.Offset+delta > offset {
+			break
+		}
+		origin = edit.origin
+		delta += len(edit.NewText) - (edit.End - edit.Offset)
+		last = edit.Edit
+	}
+	return origin
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+)
+
+func TestCheckFixes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	mod := basicModule(t)
+	basic1 := filepath.Join(mod, "basic1.go")
+	results, err := ctxtodo.AnalyzePatterns(mod, []string{"./..."})
+	if err != nil {
+		t.Fatalf("AnalyzePatterns: %s", err)
+	}
+
+	fixed, rejected, err := checkFixes(mod, []string{"./..."}, results)
+	if err != nil {
+		t.Fatalf("checkFixes: %s", err)
+	}
+	if len(rejected) > 0 {
+		t.Fatalf("checkFixes rejected %s: %q", rejected[0].pkg.ID, rejected[0].errors)
+	}
+	if _, ok := fixed[basic1]; !ok {
+		t.Fatalf("checkFixes didn't fix %s (fixed %q)", basic1, sortedKeys(fixed))
+	}
+
+	// Break the edit that replaces the first context.TODO() call.
+	src, err := os.ReadFile(basic1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var broken *ctxtodo.Result
+	for i := range results {
+		for _, fix := range results[i].Fixes {
+			for j, edit := range fix.Edits {
+				if broken == nil && string(src[edit.Offset:edit.End]) == "context.TODO()" {
+					fix.Edits[j].NewText = "brokenCtx"
+					broken = &results[i]
+				}
+			}
+		}
+	}
+	if broken == nil {
+		t.Fatal("no fix replaces a context.TODO() call")
+	}
+
+	fixed, rejected, err = checkFixes(mod, []string{"./..."}, results)
+	if err != nil {
+		t.Fatalf("checkFixes: %s", err)
+	}
+	if _, ok := fixed[basic1]; ok {
+		t.Errorf("checkFixes fixed %s with a broken edit", basic1)
+	}
+	if len(rejected) != 1 {
+		t.Fatalf("checkFixes rejected %d packages, want 1", len(rejected))
+	}
+	got := strings.Join(rejected[0].errors, "\n")
+	if want := "undefined: brokenCtx (from the fix for " + broken.Pos.String() + ": "; !strings.Contains(got, want) {
+		t.Errorf("rejection errors:\n%s\nwant them to contain %q", got, want)
+	}
+}
+
+// TestCheckFixesTests ensures that the packages without diagnostics that the fixes break,
+// like a test calling a function that gains a ctx parameter, are checked too.
+func TestCheckFixesTests(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	mod := basicModule(t)
+	basic1 := filepath.Join(mod, "basic1.go")
+	results, err := ctxtodo.AnalyzePatterns(mod, []string{"./..."})
+	if err != nil {
+		t.Fatalf("AnalyzePatterns: %s", err)
+	}
+
+	// The tests aren't analyzed, so the call isn't fixed when b gains a ctx parameter.
+	test := "package basic\n\nimport \"testing\"\n\nfunc TestB(t *testing.T) { b(\"localhost:0\") }\n"
+	if err := os.WriteFile(filepath.Join(mod, "basic_test.go"), []byte(test), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixed, rejected, err := checkFixes(mod, []string{"./..."}, results)
+	if err != nil {
+		t.Fatalf("checkFixes: %s", err)
+	}
+	if _, ok := fixed[basic1]; ok {
+		t.Errorf("checkFixes fixed %s, breaking its test", basic1)
+	}
+	var got []string
+	for _, rej := range rejected {
+		got = append(got, rej.pkg.ID)
+		if rej.with == nil && !strings.Contains(strings.Join(rej.errors, "\n"), "not enough arguments in call to b") {
+			t.Errorf("%s rejection errors: %q, want a missing argument to b", rej.pkg.ID, rej.errors)
+		}
+	}
+	if want := []string{"basic [basic.test]", "basic"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("checkFixes rejected %q, want %q", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	want, err := os.ReadFile(filepath.Join(demoTestdata, "main.go.golden"))
+	if err != nil {
+		t.Fatalf("reading golden: %s", err)
+	}
+	run, mainGo := demoModule(t)
+
+	// The fixes of the demo all type-check, so it is fixed just like with -fix.
+	out, err := run("-validate", "./...")
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Fatalf("plumber -validate = %v, want exit status 3 for reported diagnostics\n%s", err, out)
+	}
+	if !bytes.Contains(out, []byte("Plumb context")) {
+		t.Errorf("plumber -validate output is missing diagnostics:\n%s", out)
+	}
+
+	got, err := os.ReadFile(mainGo)
+	if err != nil {
+		t.Fatalf("reading fixed output: %s", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("plumber -validate output does not match golden file\n--- got:\n%s\n--- want:\n%s\n--- output:\n%s", got, want, out)
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"fmt"
+	"go/build"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// A Result is a diagnostic reported by Analyze, with its positions resolved.
+type Result struct {
+	Package  *packages.Package // the package it was reported for
+	Pos, End token.Position
+	Category string
+	Message  string
+	Fixes    []Fix
+}
+
+// A Fix is a suggested fix for a Result.
+type Fix struct {
+	Message string
+	Edits   []Edit
+}
+
+// An Edit replaces the bytes of Filename from Offset up to (but not including) End with NewText.
+type Edit struct {
+	Filename    string
+	Offset, End int
+	NewText     string
+}
+
+// Analyze analyzes pkgs like the Analyzer, without an analysis driver: they must have been
+// loaded with (at least) packages.LoadAllSyntax, and their diagnostics are returned in the
+// order they were reported.  Unlike the Analyzer, Analyze doesn't write them as JSON or
+// drop their fixes for -json-diagnostics or -dry-run, since its caller reports them.
+//
+// So that facts are available, the dependencies of pkgs that can be edited along with
+// them are also analyzed, in dependency order: those in the main module
+// (or, for packages loaded without modules, those outside of GOROOT).  Other
+// dependencies (including the standard library) never need a context, so they are skipped.
+// Only the diagnostics for pkgs themselves are returned.
+func Analyze(pkgs []*packages.Package) ([]Result, error) {
+	flagsOnce = sync.Once{} // the flags may have changed since the last call
+	isRoot := map[*packages.Package]bool{}
+	for _, pkg := range pkgs {
+		isRoot[pkg] = true
+	}
+
+	f := newFacts()
+	var results []Result
+	var runErr error
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if runErr != nil || !isRoot[pkg] && !isAnalyzedDependency(pkg) {
+			return
+		}
+		if pkg.Types == nil || pkg.TypesInfo == nil {
+			runErr = fmt.Errorf("analyzing %s: package was loaded without type information", pkg.PkgPath)
+			return
+		}
+		var diags []analysis.Diagnostic
+		pass := &analysis.Pass{
+			Analyzer:          Analyzer,
+			Fset:              pkg.Fset,
+			Files:             pkg.Syntax,
+			OtherFiles:        pkg.OtherFiles,
+			Pkg:               pkg.Types,
+			TypesInfo:         pkg.TypesInfo,
+			TypesSizes:        pkg.TypesSizes,
+			Report:            func(d analysis.Diagnostic) { diags = append(diags, d) },
+			ResultOf:          map[*analysis.Analyzer]interface{}{},
+			ImportObjectFact:  f.importObjectFact,
+			ExportObjectFact:  f.exportObjectFact,
+			ImportPackageFact: f.importPackageFact,
+			ExportPackageFact: func(fact analysis.Fact) { f.exportPackageFact(pkg.Types, fact) },
+			AllObjectFacts:    f.allObjectFacts,
+			AllPackageFacts:   f.allPackageFacts,
+		}
+		if err := analyzePackage(pass); err != nil {
+			runErr = fmt.Errorf("analyzing %s: %w", pkg.PkgPath, err)
+			return
+		}
+		if isRoot[pkg] {
+			for _, diag := range diags {
+				results = append(results, resultFor(pkg, diag))
+			}
+		}
+	})
+	if runErr != nil {
+		return nil, runErr
+	}
+	return results, nil
+}
+
+// AnalyzePatterns loads the packages matching patterns (relative to dir) and runs Analyze
+// on them, for drivers that report the diagnostics themselves.  Test files are not analyzed.
+func AnalyzePatterns(dir string, patterns []string) ([]Result, error) {
+	cfg := &packages.Config{
+		Mode: packages.LoadAllSyntax | packages.NeedModule,
+		Dir:  dir,
+	}
+	roots, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("%v matched no packages", patterns)
+	}
+	if n := packages.PrintErrors(roots); n > 0 {
+		return nil, fmt.Errorf("%d errors loading packages", n)
+	}
+	return Analyze(roots)
+}
+
+// isAnalyzedDependency returns true if Analyze should run on the dependency pkg.
+func isAnalyzedDependency(pkg *packages.Package) bool {
+	if pkg.Module != nil {
+		return pkg.Module.Main
+	}
+	if len(pkg.GoFiles) == 0 {
+		return false
+	}
+	goroot := filepath.Join(build.Default.GOROOT, "src") + string(filepath.Separator)
+	return !strings.HasPrefix(pkg.GoFiles[0], goroot)
+}
+
+// resultFor resolves the positions of diag, which was reported for pkg.
+func resultFor(pkg *packages.Package, diag analysis.Diagnostic) Result {
+	start := pkg.Fset.Position(diag.Pos)
+	end := start
+	if diag.End.IsValid() {
+		end = pkg.Fset.Position(diag.End)
+	}
+	result := Result{
+		Package:  pkg,
+		Pos:      start,
+		End:      end,
+		Category: diag.Category,
+		Message:  diag.Message,
+	}
+	for _, fix := range diag.SuggestedFixes {
+		rfix := Fix{Message: fix.Message}
+		for _, te := range fix.TextEdits {
+			pos := pkg.Fset.PositionFor(te.Pos, false) // edits are to the file itself, not its //line source
+			rfix.Edits = append(rfix.Edits, Edit{
+				Filename: pos.Filename,
+				Offset:   pos.Offset,
+				End:      offset(pkg.Fset, te.End, pos),
+				NewText:  string(te.NewText),
+			})
+		}
+		result.Fixes = append(result.Fixes, rfix)
+	}
+	return result
+}
+
+// facts stores the facts exported by each package analyzed by Analyze.
+//
+// Since all packages are type-checked from source in a single load, their
+// objects are shared and facts don't need to be serialized.
+type facts struct {
+	objects  map[objectFactKey]analysis.Fact
+	packages map[packageFactKey]analysis.Fact
+}
+
+type objectFactKey struct {
+	obj types.Object
+	typ reflect.Type
+}
+
+type packageFactKey struct {
+	pkg *types.Package
+	typ reflect.Type
+}
+
+func newFacts() *facts {
+	return &facts{
+		objects:  map[objectFactKey]analysis.Fact{},
+		packages: map[packageFactKey]analysis.Fact{},
+	}
+}
+
+func (f *facts) importObjectFact(obj types.Object, fact analysis.Fact) bool {
+	stored, ok := f.objects[objectFactKey{obj, reflect.TypeOf(fact)}]
+	if ok {
+		reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(stored).Elem())
+	}
+	return ok
+}
+
+func (f *facts) exportObjectFact(obj types.Object, fact analysis.Fact) {
+	f.objects[objectFactKey{obj, reflect.TypeOf(fact)}] = fact
+}
+
+func (f *facts) importPackageFact(pkg *types.Package, fact analysis.Fact) bool {
+	stored, ok := f.packages[packageFactKey{pkg, reflect.TypeOf(fact)}]
+	if ok {
+		reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(stored).Elem())
+	}
+	return ok
+}
+
+func (f *facts) exportPackageFact(pkg *types.Package, fact analysis.Fact) {
+	f.packages[packageFactKey{pkg, reflect.TypeOf(fact)}] = fact
+}
+
+func (f *facts) allObjectFacts() (all []analysis.ObjectFact) {
+	for key, fact := range f.objects {
+		all = append(all, analysis.ObjectFact{Object: key.obj, Fact: fact})
+	}
+	return all
+}
+
+func (f *facts) allPackageFacts() (all []analysis.PackageFact) {
+	for key, fact := range f.packages {
+		all = append(all, analysis.PackageFact{Package: key.pkg, Fact: fact})
+	}
+	return all
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// audit reports each context.TODO() (without suggested fixes) for OnlyTODOs,
+// noting whether a context is already in scope and, if not, the shortest chain
+// of calls to it from a function that has one.
+func (r *runner) audit() {
+	for _, todo := range r.todos {
+		if todo.background {
+			continue
+		}
+		r.pending = append(r.pending, analysis.Diagnostic{
+			Pos:      todo.call.Pos(),
+			End:      todo.call.End(),
+			Category: CategoryAudit,
+			Message:  r.auditMessage(todo),
+		})
+	}
+}
+
+func (r *runner) auditMessage(todo localCall) string {
+	at := todo.call.Pos()
+	if todo.assign != nil {
+		at = todo.assign.Pos()
+	}
+	decl := todo.path.decl()
+	if decl == nil {
+		expr, _ := r.rootContext(at)
+		return fmt.Sprintf("context.TODO() at package level can use %s", expr)
+	}
+	if expr, ok := r.hasContextProviderInPath(todo.path, at); ok {
+		return fmt.Sprintf("context.TODO() in %s can use %s", decl.Name.Name, expr)
+	}
+	if entry, expr, chain, ok := r.nearestEntry(decl); ok {
+		return fmt.Sprintf("context.TODO() in %s can be plumbed from %s (%s): %s", decl.Name.Name, entry, expr, strings.Join(chain, " -> "))
+	}
+	return fmt.Sprintf("context.TODO() in %s is not reachable from a function with a context", decl.Name.Name)
+}
+
+// nearestEntry searches the callers of decl (breadth first, so the shortest chain of
+// calls is found) for a function with a context to pass along: one with a context
+// in scope at the call, or a root like main, a test function, or one of Entrypoints.
+//
+// It returns the name of that function, the expression for its context, and the names
+// of the functions in the chain of calls from it to decl.
+func (r *runner) nearestEntry(decl *ast.FuncDecl) (entry, expr string, chain []string, ok bool) {
+	type step struct {
+		decl *ast.FuncDecl
+		next *step // toward the context.TODO()
+	}
+	names := func(s *step) (chain []string) {
+		for ; s != nil; s = s.next {
+			chain = append(chain, s.decl.Name.Name)
+		}
+		return chain
+	}
+
+	seen := map[*ast.FuncDecl]bool{decl: true}
+	queue := []*step{{decl: decl}}
+	for len(queue) > 0 {
+		callee := queue[0]
+		queue = queue[1:]
+		for _, caller := range r.callers[r.TypesInfo.ObjectOf(callee.decl.Name)] {
+			from := caller.path.decl()
+			if from == nil {
+				expr, _ := r.rootContext(caller.call.Pos())
+				return "package level", expr, names(callee), true
+			}
+			s := &step{decl: from, next: callee}
+			if expr, ok := r.hasContextProviderInPath(caller.path, caller.call.Pos()); ok {
+				return from.Name.Name, expr, names(s), true
+			}
+			if r.isRoot(from) {
+				expr, _ := r.rootContext(from.Pos())
+				return from.Name.Name, expr, names(s), true
+			}
+			if !seen[from] {
+				seen[from] = true
+				queue = append(queue, s)
+			}
+		}
+	}
+	return "", "", nil, false
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// cacheVersion is part of every cache key, and must be changed whenever a change to
+// the analyzer could cause a package that needed no changes to need some.
+const cacheVersion = "ctxtodo cache v1"
+
+// A packageCache remembers the packages that needed no changes (they have no
+// context.TODO() calls and call no functions that need a context), so that the
+// walk of their syntax can be skipped when they're analyzed again.  Only that is
+// cached: the call graphs of the packages that need changes are not, so they are
+// built again every time.
+//
+// Entries are keyed by everything that determines whether a package needs changes:
+// the contents of its files, the analyzer's flags, and the facts imported from its
+// dependencies.  Packages that need changes are always analyzed in full, since their
+// diagnostics refer to the syntax trees of the current run.
+type packageCache struct {
+	dir string
+	key string
+}
+
+// newPackageCache returns the cache entry for the package in p, or nil if caching is
+// disabled or the package's files can't be read.
+func newPackageCache(p *analysis.Pass, logger *logger) *packageCache {
+	if CacheDir == "" {
+		return nil
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\npackage %s\n", cacheVersion, p.Pkg.Path())
+	fmt.Fprint(h, flagValues())
+
+	var filenames []string
+	for _, file := range p.Files {
+		filenames = append(filenames, filename(p.Fset, file.Pos()))
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			logger.Infof("Not caching %s: %s", p.Pkg.Path(), err)
+			return nil
+		}
+		fmt.Fprintf(h, "file %s %d\n", filename, len(src))
+		h.Write(src)
+	}
+
+	var facts []string
+	for _, fact := range p.AllObjectFacts() {
+		name := fact.Object.Pkg().Path() + "." + fact.Object.Name()
+		if fun, ok := fact.Object.(*types.Func); ok {
+			name = fun.FullName() // includes the receiver of methods
+		}
+		facts = append(facts, fmt.Sprintf("fact %s %s\n", fact.Fact, name))
+	}
+	sort.Strings(facts)
+	for _, fact := range facts {
+		fmt.Fprint(h, fact)
+	}
+
+	return &packageCache{
+		dir: CacheDir,
+		key: fmt.Sprintf("%x", h.Sum(nil)),
+	}
+}
+
+// cacheFlags has the same values as Analyzer.Flags, which newPackageCache can't refer to
+// (the Analyzer refers to it).  It is only read, since the packages are analyzed concurrently.
+var cacheFlags = flags()
+
+var (
+	flagsOnce     sync.Once
+	flagsSnapshot string
+)
+
+// flagValues returns the values of the analyzer's flags for the cache keys.  They are
+// read once per run (or per call of Analyze), since they don't change during one.
+func flagValues() string {
+	flagsOnce.Do(func() {
+		var b strings.Builder
+		cacheFlags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(&b, "flag %s=%s\n", f.Name, f.Value)
+		})
+		flagsSnapshot = b.String()
+	})
+	return flagsSnapshot
+}
+
+func (c *packageCache) path() string {
+	return filepath.Join(c.dir, c.key[:2], c.key)
+}
+
+// unchanged returns true if the package was previously found to need no changes.
+func (c *packageCache) unchanged() bool {
+	if c == nil {
+		return false
+	}
+	_, err := os.Stat(c.path())
+	return err == nil
+}
+
+// markUnchanged records that the package needs no changes.
+func (c *packageCache) markUnchanged(logger *logger) {
+	if c == nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path()), 0755); err != nil {
+		logger.Infof("Not caching: %s", err)
+		return
+	}
+	if err := os.WriteFile(c.path(), nil, 0644); err != nil {
+		logger.Infof("Not caching: %s", err)
+	}
+}
@@ -0,0 +1,3308 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ctxtodo implements a Go Analyzer for detecting context.TODO() and plumbing contexts to it.
+package ctxtodo
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer provides the ctxtodo analyzer.
+var Analyzer = &analysis.Analyzer{
+	Name:  "ctxtodo",
+	Doc:   "Find calls of the context.TODO function in need of plumbing.",
+	Run:   run,
+	Flags: flags(),
+
+	FactTypes: []analysis.Fact{
+		new(NeedsContext), // propagate the necessity of adding ctx parameters
+	},
+
+	// We want to be able to add context parameters where they were missing,
+	// so we will need to run even if there were some type-checking errors.
+	RunDespiteErrors: true,
+}
+
+var (
+	// ModuleCache is a prefix that will cause suggested fixes to be ignored.
+	ModuleCache string
+
+	// CacheDir is where packages that need no changes are remembered, so they can be
+	// skipped quickly when they are analyzed again (it defaults to a directory in the
+	// Go build cache).  Nothing else about the packages (like their call graphs) is
+	// cached.  If it is empty, nothing is cached.
+	CacheDir string
+
+	// FixMode selects how context.TODO() calls are fixed: FixPlumb plumbs a
+	// context through the call graph, and FixBackground simply replaces each of
+	// them with context.Background().
+	FixMode = FixPlumb
+
+	// CtxPosition selects where new ctx parameters (and the arguments passed to
+	// them) go: PositionFirst, or PositionLast for codebases that put them last.
+	// Variadic functions always get them first.
+	CtxPosition = PositionFirst
+
+	// ExcludeFiles are glob patterns (matched against the full path and the base
+	// name) of files that should not be edited.  Context is still plumbed through
+	// them, so the fixes for other files may be inconsistent without manual edits.
+	ExcludeFiles []string
+
+	// PackageAllowlist are import path prefixes of the packages that can be edited.
+	// If it is empty, all packages can be edited.  Context is still plumbed through
+	// other packages, so that packages which are allowed can be edited correctly.
+	PackageAllowlist []string
+
+	// GitBase, if set, is a git revision (like "origin/main" or "HEAD") such that only
+	// files changed since then (including uncommitted changes) can be edited, for
+	// migrating incrementally as files are touched.  Like PackageAllowlist, context
+	// is still plumbed through other files, so that the edits that remain are correct.
+	GitBase string
+
+	// MaxDepth limits how many levels of callers (starting with the function
+	// containing the context.TODO()) will gain a ctx parameter.  Callers beyond
+	// this depth will use context.Background() instead.  Zero means unlimited.
+	MaxDepth int
+
+	// NoCrossPackage restricts plumbing to within each package: exported functions
+	// don't gain a ctx parameter (using context.Background() instead), and no facts
+	// are exported to, or imported from, other packages.
+	NoCrossPackage bool
+
+	// ContextName is the name of the context variables and parameters that
+	// will be matched and created.
+	ContextName = "ctx"
+
+	// RootContext is the expression used to obtain a context in functions that
+	// can't gain a ctx parameter (like main and top-level tests).
+	RootContext = defaultRootContext
+
+	// Entrypoints are regular expressions matching the names of additional functions
+	// (like handlers registered by name) that are treated as roots like main: they use
+	// a context from their parameters or the RootContext instead of gaining a ctx
+	// parameter.  Each must match the whole name, which is "Type.Method" for methods.
+	Entrypoints []string
+
+	// ProviderFuncs are functions that call the function literals passed to them with
+	// a context, as "regexp=index" (like "withRequest=0"): the literal's parameter at that
+	// index (from 0) is used as the context within it, with a type assertion if it is
+	// declared as another interface (like interface{}).  Like Entrypoints, each regular
+	// expression must match the whole name, which is "Type.Method" for methods.
+	ProviderFuncs []string
+
+	// ParamNames are the names given to unnamed parameters (so that a context can be
+	// obtained from them), as "type=name" (like "*http.Request=req").  Types without
+	// one are named after their first letter (like "r"), or ContextName for context.Context.
+	ParamNames []string
+
+	// ParamComment, if set, is a comment added next to each ctx parameter that
+	// is created (like "TODO: plumbed automatically"), so they can be found later.
+	ParamComment string
+
+	// IncludeBackground causes context.Background() calls to be treated like
+	// context.TODO() when there is already a context available to replace them.
+	IncludeBackground bool
+
+	// ContextImportPaths are the import paths of the packages that provide
+	// context.TODO, context.Background, and context.Context, for codebases that
+	// use a package re-exporting them (like "internal/ctx").  The first one is
+	// imported by files that need a context but don't import any of them.
+	ContextImportPaths = []string{"context"}
+
+	// DeepProviderSearch allows a context to be obtained from a field of a struct
+	// parameter (like "req.Ctx" or "env.Request.Context()") when no parameter can
+	// provide one itself.  Only one level of fields is searched.
+	DeepProviderSearch bool
+
+	// ContextMethods are the names of additional methods (beyond Context) that
+	// can provide a context.  They must take no arguments and return only a
+	// context.Context to be used.
+	ContextMethods []string
+
+	// FixComments causes comments matching FixCommentsPattern directly above a
+	// context.TODO() being plumbed (like "// TODO: plumb context") to be removed
+	// by the same fix, since they would otherwise be stale.
+	FixComments bool
+
+	// FixCommentsPattern is the regular expression matched against the text of
+	// the comments considered by FixComments.
+	FixCommentsPattern = `(?i)\bTODO\b.*\bcontext\b`
+
+	// OnlyTODOs causes each context.TODO() to be reported without a suggested fix,
+	// noting whether a context is already available, or else the shortest chain of
+	// calls from a function that has one, for auditing them before plumbing.
+	OnlyTODOs bool
+
+	// DryRun causes diagnostics to be reported without suggested fixes, and a
+	// plan of the edits that would have been made to be written to Output.
+	DryRun bool
+
+	// ReportOnlyUnfixable suppresses the diagnostics that have suggested fixes, so that
+	// only the ones that need manual work (like a callback whose signature is fixed) are
+	// reported, as a punch list after the fixes have been applied.
+	ReportOnlyUnfixable bool
+
+	// JSONDiagnostics causes each diagnostic, including the edits of its
+	// suggested fixes (as file offsets), to be written to Output as a line of JSON.
+	JSONDiagnostics bool
+
+	// MaxFiles limits how many files the fix for a single context.TODO() (or call
+	// to a function in another package that gained a ctx parameter) can edit.
+	// Beyond that, the fix is dropped and it is reported to be done manually.
+	// The default of 0 is unlimited.
+	MaxFiles int
+
+	// CompatShim keeps the signature of exported functions gaining a ctx parameter
+	// for other packages: the function is renamed (like New to NewWithContext), and a
+	// deprecated shim with its original name calls it with the RootContext.
+	CompatShim bool
+
+	// AnnotateReach adds the number of functions and files edited by the fix of
+	// each plumbing diagnostic to its message, so that reviewers can prioritize them.
+	AnnotateReach bool
+
+	// Summary causes a line counting the context.TODO() calls found, the functions
+	// and files that were changed, and the diagnostics that could not be fixed to
+	// be written to Output for each package.
+	Summary bool
+
+	// Verbose causes progress messages (like which functions are gaining a
+	// context parameter) to be logged in addition to warnings.
+	Verbose bool
+
+	// Output is where reports (like the DryRun plan) are written.
+	Output io.Writer = os.Stdout
+	outMu  sync.Mutex
+
+	// LogOutput is where log messages are written.
+	LogOutput io.Writer = os.Stderr
+)
+
+const defaultRootContext = "context.Background()"
+
+// Values for FixMode.
+const (
+	FixPlumb      = "plumb"
+	FixBackground = "background"
+)
+
+// Categories of the diagnostics that are reported, so that tools can filter them.
+const (
+	CategoryPlumb      = "context.plumb"      // a context is plumbed to the call, adding ctx parameters
+	CategorySubstitute = "context.substitute" // the call is replaced with a context that is available
+	CategoryTransitive = "context.transitive" // a function in another package gained a ctx parameter
+	CategoryManual     = "context.manual"     // something plumber can't fix needs attention
+	CategoryAudit      = "context.audit"      // a context.TODO() found by OnlyTODOs
+)
+
+// Values for CtxPosition.
+const (
+	PositionFirst = "first"
+	PositionLast  = "last"
+)
+
+func init() {
+	env, _ := exec.Command("go", "env", "GOMODCACHE", "GOCACHE").Output()
+	if vars := strings.Split(strings.TrimSpace(string(env)), "\n"); len(vars) == 2 {
+		ModuleCache = vars[0]
+		if gocache := vars[1]; gocache != "" && gocache != "off" {
+			CacheDir = filepath.Join(gocache, "plumber")
+		}
+	}
+}
+
+// exprValue is a flag.Value for a string that must be a valid Go expression.
+type exprValue string
+
+func (e *exprValue) String() string {
+	return string(*e)
+}
+
+func (e *exprValue) Set(value string) error {
+	if _, err := parser.ParseExpr(value); err != nil {
+		return fmt.Errorf("invalid Go expression %q: %s", value, err)
+	}
+	*e = exprValue(value)
+	return nil
+}
+
+// commentValue is a flag.Value for a string that can be used as the text of
+// either a line or a block comment.
+type commentValue string
+
+func (c *commentValue) String() string {
+	return string(*c)
+}
+
+func (c *commentValue) Set(value string) error {
+	if err := checkComment(value); err != nil {
+		return err
+	}
+	*c = commentValue(value)
+	return nil
+}
+
+// checkComment returns an error if text can't be used as the text of a comment.
+func checkComment(text string) error {
+	if strings.ContainsAny(text, "\r\n") || strings.Contains(text, "*/") {
+		return fmt.Errorf("comment %q must be a single line without \"*/\"", text)
+	}
+	return nil
+}
+
+// defaultedList is a flag.Value that replaces its default value the first time it is set,
+// and then accumulates repeated or comma-separated values like stringList.
+type defaultedList struct {
+	list *[]string
+	set  bool
+}
+
+func (l *defaultedList) String() string {
+	if l.list == nil {
+		return ""
+	}
+	return strings.Join(*l.list, ",")
+}
+
+func (l *defaultedList) Set(value string) error {
+	if !l.set {
+		*l.list, l.set = nil, true
+	}
+	return (*stringList)(l.list).Set(value)
+}
+
+// Reset clears the list, including its default value.
+func (l *defaultedList) Reset() {
+	*l.list, l.set = nil, true
+}
+
+// stringList is a flag.Value that accumulates repeated or comma-separated values.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// Reset clears the list, so that the values set afterward replace it (like those given
+// on the command line, over the ones from a config file).
+func (l *stringList) Reset() {
+	*l = nil
+}
+
+func flags() flag.FlagSet {
+	flag := flag.NewFlagSet("ctxtodo", flag.ContinueOnError)
+	flag.StringVar(&ModuleCache, "modcache", ModuleCache, "Module cache directory (ignored for fixes)")
+	flag.StringVar(&CacheDir, "cache-dir", CacheDir, "Directory for caching which packages need no changes (empty to disable)")
+	flag.Var((*stringList)(&ExcludeFiles), "exclude-files", "Glob `pattern`s of files not to edit (repeated or comma-separated)")
+	flag.Var((*stringList)(&PackageAllowlist), "package-allowlist", "Import path `prefix`es of the only packages to edit (repeated or comma-separated)")
+	flag.StringVar(&GitBase, "git-base", GitBase, "Only edit files changed since this git `revision` (including uncommitted changes)")
+	flag.StringVar(&FixMode, "fixmode", FixMode, "How to fix context.TODO() calls: plumb a context (plumb) or use context.Background() (background)")
+	flag.StringVar(&CtxPosition, "ctx-position", CtxPosition, "Where to add ctx parameters: first or last (variadic functions always get them first)")
+	flag.StringVar(&ContextName, "ctxname", ContextName, "Name of context variables and parameters")
+	flag.Var(&defaultedList{list: &ContextImportPaths}, "context-import-path", "Import `path`s of packages providing context.TODO and context.Context (repeated or comma-separated)")
+	flag.Var((*stringList)(&ContextMethods), "context-method-names", "Additional method `name`s that provide a context (repeated or comma-separated)")
+	flag.BoolVar(&DeepProviderSearch, "deep-provider-search", DeepProviderSearch, "Also obtain a context from the fields of struct parameters")
+	flag.BoolVar(&FixComments, "fix-comments", FixComments, "Remove stale comments about context (see --fix-comments-pattern) above fixed context.TODO() calls")
+	flag.StringVar(&FixCommentsPattern, "fix-comments-pattern", FixCommentsPattern, "Regular `expression` matching the comments removed by --fix-comments")
+	flag.BoolVar(&OnlyTODOs, "only-todos", OnlyTODOs, "Only report each context.TODO() and where it could get a context, without fixes")
+	flag.BoolVar(&DryRun, "dry-run", DryRun, "Print a plan of the edits instead of suggesting fixes")
+	flag.BoolVar(&ReportOnlyUnfixable, "report-only-unfixable", ReportOnlyUnfixable, "Only report the diagnostics without fixes, which need manual work")
+	flag.BoolVar(&JSONDiagnostics, "json-diagnostics", JSONDiagnostics, "Also write diagnostics and their edits as lines of JSON")
+	flag.Var((*stringList)(&ParamNames), "param-names", "Names for unnamed parameters, as `type=name` (repeated or comma-separated)")
+	flag.Var((*commentValue)(&ParamComment), "param-comment", "Comment `text` to add next to each new ctx parameter")
+	flag.Var((*stringList)(&Entrypoints), "entrypoints", "Regular `expression`s matching the names of functions to treat as roots like main (repeated or comma-separated)")
+	flag.Var((*stringList)(&ProviderFuncs), "provider-funcs", "Functions that call their function literal arguments with a context, as `regexp=index` of the context parameter (repeated or comma-separated)")
+	flag.Var((*exprValue)(&RootContext), "root-context", "Go `expr`ession for the context in functions that can't gain a ctx parameter")
+	flag.BoolVar(&IncludeBackground, "include-background", IncludeBackground, "Also replace context.Background() where a context is available")
+	flag.IntVar(&MaxFiles, "max-files", MaxFiles, "Maximum number of files the fix for a single context.TODO() can edit (0 for unlimited)")
+	flag.IntVar(&MaxDepth, "maxdepth", MaxDepth, "Maximum levels of callers to add a ctx parameter to (0 for unlimited)")
+	flag.BoolVar(&NoCrossPackage, "no-cross-package", NoCrossPackage, "Only plumb context within each package, without changing exported functions")
+	flag.BoolVar(&CompatShim, "compat-shim", CompatShim, "Rename exported functions gaining a ctx parameter (like New to NewWithContext), keeping a deprecated shim with the original signature")
+	flag.BoolVar(&AnnotateReach, "annotate-reach", AnnotateReach, "Note how many functions and files each plumbing fix edits in its message")
+	flag.BoolVar(&Summary, "summary", Summary, "Print a summary of the changes made to each package")
+	flag.BoolVar(&Verbose, "verbose", Verbose, "Log progress messages in addition to warnings")
+	return *flag
+}
+
+// NeedsContext indicates that an exported function is having a context added
+// by the ctxtodo analyzer, so that other packages can understand the need to
+// add a context parameter.
+type NeedsContext struct{}
+
+func (NeedsContext) AFact()         {}
+func (NeedsContext) String() string { return "NeedsContext" }
+
+// TODO(kevlar): Potential future improvements:
+//  - Add a --stop repeated regex flag to prevent plumbing through matched functions
+//  - Detect calls like (foo) to functions taking (context, foo)
+
+// run analyzes the package of pass for the analysis driver, which also shows the
+// diagnostics as JSON or without their fixes, if requested.
+func run(pass *analysis.Pass) (interface{}, error) {
+	logger := newLogger()
+	if JSONDiagnostics {
+		writeJSON(pass, logger)
+	}
+	if DryRun {
+		stripFixes(pass)
+	}
+	return nil, analyzePackage(pass)
+}
+
+// analyzePackage analyzes the package of pass, reporting its diagnostics with pass.Report.
+// It is shared by the Analyzer and Analyze.
+func analyzePackage(pass *analysis.Pass) error {
+	if ModuleCache == "" {
+		return fmt.Errorf("failed to determine GOMODCACHE, specify --modcache flag")
+	}
+	if MaxDepth < 0 {
+		return fmt.Errorf("invalid --maxdepth %d, must not be negative", MaxDepth)
+	}
+	if MaxFiles < 0 {
+		return fmt.Errorf("invalid --max-files %d, must not be negative", MaxFiles)
+	}
+	if !token.IsIdentifier(ContextName) || ContextName == "_" {
+		return fmt.Errorf("invalid --ctxname %q, must be a Go identifier", ContextName)
+	}
+	switch FixMode {
+	case FixPlumb, FixBackground:
+	default:
+		return fmt.Errorf("invalid --fixmode %q, must be %q or %q", FixMode, FixPlumb, FixBackground)
+	}
+	switch CtxPosition {
+	case PositionFirst, PositionLast:
+	default:
+		return fmt.Errorf("invalid --ctx-position %q, must be %q or %q", CtxPosition, PositionFirst, PositionLast)
+	}
+	if _, err := parser.ParseExpr(RootContext); err != nil {
+		return fmt.Errorf("invalid --root-context %q: %s", RootContext, err)
+	}
+	if err := checkComment(ParamComment); err != nil {
+		return fmt.Errorf("invalid --param-comment: %s", err)
+	}
+	staleComment, err := regexp.Compile(FixCommentsPattern)
+	if err != nil {
+		return fmt.Errorf("invalid --fix-comments-pattern %q: %s", FixCommentsPattern, err)
+	}
+	var entrypoints []*regexp.Regexp
+	for _, pattern := range Entrypoints {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid --entrypoints %q: %s", pattern, err)
+		}
+		entrypoints = append(entrypoints, re)
+	}
+	var providerFuncs []providerFunc
+	for _, entry := range ProviderFuncs {
+		pf, err := parseProviderFunc(entry)
+		if err != nil {
+			return fmt.Errorf("invalid --provider-funcs %q: %s", entry, err)
+		}
+		providerFuncs = append(providerFuncs, pf)
+	}
+	for _, pattern := range ExcludeFiles {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --exclude-files %q: %s", pattern, err)
+		}
+	}
+	if len(ContextImportPaths) == 0 {
+		return fmt.Errorf("invalid --context-import-path, at least one is required")
+	}
+	for _, entry := range ParamNames {
+		if typ, name := splitParamName(entry); typ == "" || !token.IsIdentifier(name) || name == "_" {
+			return fmt.Errorf("invalid --param-names %q, must be type=name", entry)
+		}
+	}
+	for _, name := range ContextMethods {
+		if !token.IsIdentifier(name) || name == "_" {
+			return fmt.Errorf("invalid --context-method-names %q, must be a Go identifier", name)
+		}
+	}
+	logger := newLogger()
+	if ReportOnlyUnfixable {
+		onlyUnfixable(pass)
+	}
+	sum := new(summary)
+	if Summary {
+		sum.countUnfixable(pass)
+	}
+	changed, err := changedFiles(pass)
+	if err != nil {
+		return fmt.Errorf("finding files changed since --git-base %q: %s", GitBase, err)
+	}
+	skip := filterReports(pass, sum, changed)
+
+	r := &runner{
+		Pass:            pass,
+		logger:          logger,
+		summary:         sum,
+		skip:            skip,
+		byObj:           map[types.Object]*ast.FuncDecl{},
+		callers:         map[types.Object][]localCall{},
+		called:          map[*ast.Ident]bool{},
+		funcVars:        map[*types.Var]funcValue{},
+		results:         map[*types.Var][]token.Pos{},
+		indirect:        map[*types.Var][]localCall{},
+		values:          map[types.Object][]*ast.Ident{},
+		ifaceMethods:    map[*types.Func]*ast.Field{},
+		providers:       map[scopeLookup][]scopeProvider{},
+		paramAdded:      map[*ast.FuncDecl]bool{},
+		ifaceAdded:      map[*types.Func]bool{},
+		litAdded:        map[*ast.FuncLit]bool{},
+		contextImported: map[*ast.File]bool{},
+		unnamedRecv:     map[*types.Var]bool{},
+		paramNames:      map[*ast.FieldList][]string{},
+		collided:        map[*ast.FuncDecl]bool{},
+		commentMaps:     map[*ast.File]ast.CommentMap{},
+		staleComment:    staleComment,
+		entrypoints:     entrypoints,
+		providerFuncs:   providerFuncs,
+		sources:         map[string][]byte{},
+		files:           map[string]*ast.File{},
+	}
+	for _, file := range pass.Files {
+		r.files[filename(pass.Fset, file.Pos())] = file
+	}
+	cache := newPackageCache(pass, logger)
+	if cache.unchanged() {
+		// There is nothing to report, but the plan and summary still cover the package
+		logger.Infof("Skipping %s: it needed no changes when last analyzed", pass.Pkg.Path())
+	} else {
+		r.buildCallGraph()
+		if len(r.todos) == 0 && len(r.transitives) == 0 {
+			cache.markUnchanged(logger)
+		}
+		if OnlyTODOs {
+			r.audit()
+		} else {
+			r.buildDiagnostics()
+		}
+		r.reportDiagnostics()
+	}
+	if DryRun {
+		r.writePlan()
+	}
+	if Summary {
+		r.writeSummary()
+	}
+	return nil
+}
+
+type runner struct {
+	*analysis.Pass
+	logger  *logger
+	summary *summary
+	skip    func(filename string) (why string) // from filterReports
+
+	// Analysis State
+	files       map[string]*ast.File // by filename, for files compiled into the package
+	byObj       map[types.Object]*ast.FuncDecl
+	callers     map[types.Object][]localCall  // callers[target] = [funcs calling target]
+	called      map[*ast.Ident]bool           // identifiers of called functions
+	funcVars    map[*types.Var]funcValue      // variables holding a single local function value
+	indirect    map[*types.Var][]localCall    // calls through function-valued variables
+	values      map[types.Object][]*ast.Ident // uses of local functions that aren't calls
+	methodDecls []*ast.FuncDecl               // declarations of methods, in order
+	results     map[*types.Var][]token.Pos    // named results, and the ends of the assignments to them
+	todos       []localCall
+	transitives []localCall
+
+	ifaceMethods    map[*types.Func]*ast.Field // methods of local interfaces
+	ifaceMethodList []*types.Func              // keys of ifaceMethods, in order
+
+	providers map[scopeLookup][]scopeProvider // memoized by scopeProviders
+
+	// Diagnostic state
+	paramAdded      map[*ast.FuncDecl]bool
+	ifaceAdded      map[*types.Func]bool
+	litAdded        map[*ast.FuncLit]bool
+	contextImported map[*ast.File]bool
+	imported        []*ast.File                  // keys of contextImported, in order
+	unnamedRecv     map[*types.Var]bool          // receivers reported as needing a name
+	paramNames      map[*ast.FieldList][]string  // names chosen for unnamed parameters
+	collided        map[*ast.FuncDecl]bool       // functions reported as unable to gain a context
+	commentMaps     map[*ast.File]ast.CommentMap // built as needed for FixComments
+	staleComment    *regexp.Regexp               // compiled FixCommentsPattern
+	entrypoints     []*regexp.Regexp             // compiled Entrypoints
+	providerFuncs   []providerFunc               // parsed ProviderFuncs
+	sources         map[string][]byte            // file contents, for formatting edits
+	pending         []analysis.Diagnostic        // reported once their edits are merged
+	plan            []planItem                   // only populated for DryRun
+	emitted         map[editKey]bool             // the edits reported by reportDiagnostics, for the plan
+}
+
+// filterReports wraps p.Report to drop the edits to files that shouldn't be edited,
+// counting the diagnostics dropped entirely in sum.  If changed is non-nil, only the
+// files in it can be edited.  It returns the function used to determine why a file
+// shouldn't be edited.
+func filterReports(p *analysis.Pass, sum *summary, changed map[string]bool) (skip func(filename string) (why string)) {
+	generated := generatedFiles(p)
+	allowed := isAllowedPackage(p.Pkg.Path())
+	skip = func(filename string) (why string) {
+		switch {
+		case !allowed:
+			return fmt.Sprintf("Not editing package %s (not in --package-allowlist)", p.Pkg.Path())
+		case generated[filename]:
+			return fmt.Sprintf("Not editing generated file %s", filepath.Base(filename))
+		case isExcluded(filename):
+			return fmt.Sprintf("Not editing excluded file %s", filepath.Base(filename))
+		case changed != nil && !changed[filename]:
+			return fmt.Sprintf("Not editing file %s (unchanged since --git-base %s)", filepath.Base(filename), GitBase)
+		}
+		return ""
+	}
+
+	actualReport := p.Report
+	p.Report = func(diag analysis.Diagnostic) {
+		for _, sf := range diag.SuggestedFixes {
+			for _, te := range sf.TextEdits {
+				for _, pos := range []token.Pos{te.Pos, te.End} {
+					if !pos.IsValid() {
+						continue
+					}
+					if filename := filename(p.Fset, diag.Pos); strings.HasPrefix(filename, ModuleCache) {
+						sum.unfixable++
+						return // don't try to edit files in the go module cache
+					}
+				}
+			}
+		}
+		diag.SuggestedFixes = excludeEdits(p, diag.SuggestedFixes, skip)
+		actualReport(diag)
+	}
+	return skip
+}
+
+// excludeEdits removes the edits to files that shouldn't be edited (generated files,
+// files matching ExcludeFiles, or packages not in the PackageAllowlist) from fixes,
+// reporting a warning for each file that would have been edited.
+//
+// The skip function returns why a file shouldn't be edited, or "" if it should be.
+func excludeEdits(p *analysis.Pass, fixes []analysis.SuggestedFix, skip func(filename string) (why string)) []analysis.SuggestedFix {
+	var kept []analysis.SuggestedFix
+	for _, fix := range fixes {
+		var edits []analysis.TextEdit
+		warned := map[string]bool{}
+		for _, te := range fix.TextEdits {
+			filename := filename(p.Fset, te.Pos)
+			why := skip(filename)
+			if why == "" {
+				edits = append(edits, te)
+				continue
+			}
+			if !warned[filename] {
+				warned[filename] = true
+				p.Report(analysis.Diagnostic{
+					Pos:      te.Pos,
+					Category: CategoryManual,
+					Message:  why + ", plumb context here manually",
+				})
+			}
+		}
+		if len(edits) > 0 {
+			fix.TextEdits = edits
+			kept = append(kept, fix)
+		}
+	}
+	return kept
+}
+
+var generatedHeader = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// generatedFiles returns the names of the files in p that have a generated code header.
+//
+// Per the Go convention, the header must appear in a comment before the package clause.
+func generatedFiles(p *analysis.Pass) map[string]bool {
+	generated := map[string]bool{}
+	for _, file := range p.Files {
+		for _, group := range file.Comments {
+			if group.Pos() >= file.Package {
+				break
+			}
+			for _, comment := range group.List {
+				if generatedHeader.MatchString(comment.Text) {
+					generated[filename(p.Fset, file.Pos())] = true
+				}
+			}
+		}
+	}
+	return generated
+}
+
+// isAllowedPackage returns true if path is in the PackageAllowlist (or there isn't one).
+func isAllowedPackage(path string) bool {
+	if len(PackageAllowlist) == 0 {
+		return true
+	}
+	for _, prefix := range PackageAllowlist {
+		prefix = strings.TrimSuffix(strings.TrimSuffix(prefix, "..."), "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcluded returns true if filename matches one of the ExcludeFiles patterns.
+func isExcluded(filename string) bool {
+	for _, pattern := range ExcludeFiles {
+		for _, name := range []string{filename, filepath.Base(filename)} {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stripFixes removes suggested fixes from all diagnostics reported by p.
+func stripFixes(p *analysis.Pass) {
+	actualReport := p.Report
+	p.Report = func(diag analysis.Diagnostic) {
+		diag.SuggestedFixes = nil
+		actualReport(diag)
+	}
+}
+
+// onlyUnfixable drops the diagnostics reported by p that have suggested fixes.
+//
+// It must be wrapped by filterReports, so that diagnostics whose edits are all
+// excluded (like those in generated files) are seen without them.
+func onlyUnfixable(p *analysis.Pass) {
+	actualReport := p.Report
+	p.Report = func(diag analysis.Diagnostic) {
+		if hasEdits(diag) {
+			return
+		}
+		actualReport(diag)
+	}
+}
+
+func (r *runner) isContextTODO(obj types.Object) bool {
+	fun, ok := obj.(*types.Func)
+	if !ok || fun.Pkg() == nil {
+		return false
+	}
+	return isContextPackage(fun.Pkg().Path()) && fun.Name() == "TODO"
+}
+
+func (r *runner) isContextBackground(obj types.Object) bool {
+	fun, ok := obj.(*types.Func)
+	if !ok || fun.Pkg() == nil {
+		return false
+	}
+	return isContextPackage(fun.Pkg().Path()) && fun.Name() == "Background"
+}
+
+// isContextContext returns true if otyp is a context.Context, or a Context type declared in one
+// of the ContextImportPaths.
+//
+// A package that re-exports context.Context as an alias doesn't declare a type of its own,
+// so context.Context itself is always accepted.
+func (r *runner) isContextContext(otyp types.Type) bool {
+	typ, ok := otyp.(*types.Named)
+	if !ok || typ.Obj() == nil || typ.Obj().Pkg() == nil {
+		return false
+	}
+	path := typ.Obj().Pkg().Path()
+	return (path == "context" || isContextPackage(path)) && typ.Obj().Name() == "Context"
+}
+
+// isContextPackage returns true if path is one of the ContextImportPaths.
+func isContextPackage(path string) bool {
+	for _, p := range ContextImportPaths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// buildCallGraph walks the function declarations in the package looking for calls,
+// creating a call graph and taking note of the locations of the todo calls we want to target.
+func (r *runner) buildCallGraph() {
+	walker := inspector.New(r.Files)
+	const (
+		Push = true
+		Pop  = false
+	)
+
+	walker.WithStack(nil, func(node ast.Node, op bool, stack []ast.Node) (proceed bool) {
+		switch op {
+		case Push:
+			switch n := node.(type) {
+			case *ast.FuncDecl:
+				return r.walkFuncDecl(n)
+			case *ast.TypeSpec:
+				return r.walkTypeSpec(n)
+			case *ast.FuncType:
+				r.walkResults(n)
+			case *ast.AssignStmt:
+				r.walkFuncValues(n.Lhs, n.Rhs, n.Tok == token.DEFINE)
+				r.walkResultAssigns(n)
+				return r.walkAssignStmt(stack, n)
+			case *ast.ValueSpec:
+				r.walkFuncValues(identExprs(n.Names), n.Values, true)
+			case *ast.CallExpr:
+				return r.walkCallExpr(stack, n)
+			}
+		case Pop:
+			// pass
+		}
+		return true
+	})
+	r.resolveFuncValues()
+}
+
+// buildDiagnostics builds the diagnostics for each context.TODO() and for each call
+// to a function from another package that needs a context.
+//
+// The analysis driver runs this pass on a package only after it has run on all of the
+// package's dependencies, so every NeedsContext fact (however deep the chain of
+// packages) has been exported by the time r.transitives is collected.  Each transitive
+// caller is plumbed like any other, so no separate reconciliation pass is necessary.
+func (r *runner) buildDiagnostics() {
+	for _, call := range r.todos {
+		r.rewriteTODO(call)
+	}
+	for _, transitive := range r.transitives {
+		r.rewriteTransitives(transitive)
+	}
+}
+
+// reportDiagnostics reports the pending diagnostics, making sure that no text edit
+// is suggested more than once across all of them.
+//
+// Plumbing from different roots can reach the same functions and call sites, and
+// tools like gopls reject a batch of fixes containing duplicate or overlapping edits.
+// Since later diagnostics are built assuming the earlier ones are applied, an edit
+// that duplicates or overlaps an earlier one is dropped.
+func (r *runner) reportDiagnostics() {
+	type span struct {
+		pos, end token.Pos // token.Pos values are unique across files
+	}
+	emitted := map[span]string{}
+	r.emitted = map[editKey]bool{}
+	var spans []span
+	for _, diag := range r.pending {
+		var fixes []analysis.SuggestedFix
+		for _, fix := range diag.SuggestedFixes {
+			var edits []analysis.TextEdit
+		edit:
+			for _, te := range fix.TextEdits {
+				if r.file(te.Pos) == nil {
+					// Files excluded by build constraints (or otherwise not part of the
+					// package as built) have not been analyzed, so don't touch them.
+					r.logger.Infof("%s: dropping edit outside of the analyzed files", r.Fset.Position(te.Pos))
+					continue
+				}
+				sp := span{te.Pos, te.End}
+				if !sp.end.IsValid() {
+					sp.end = sp.pos
+				}
+				if text, ok := emitted[sp]; ok {
+					if text != string(te.NewText) {
+						r.logger.Infof("%s: dropping conflicting edit %q (already replaced with %q)", r.Fset.Position(sp.pos), te.NewText, text)
+					}
+					continue
+				}
+				for _, prev := range spans {
+					if sp.pos < prev.end && prev.pos < sp.end {
+						r.logger.Infof("%s: dropping edit %q overlapping %s", r.Fset.Position(sp.pos), te.NewText, r.Fset.Position(prev.pos))
+						continue edit
+					}
+				}
+				emitted[sp] = string(te.NewText)
+				spans = append(spans, sp)
+				edits = append(edits, te)
+				if r.skip(filename(r.Fset, te.Pos)) == "" {
+					r.emitted[keyOf(te)] = true // for the plan, which leaves out the edits filterReports drops
+				}
+			}
+			if len(edits) > 0 {
+				fix.TextEdits = edits
+				fixes = append(fixes, fix)
+			}
+		}
+		diag.SuggestedFixes = fixes
+		r.Report(diag)
+	}
+}
+
+// manualf reports a diagnostic (in CategoryManual) for something that plumber can't fix.
+func (r *runner) manualf(pos, end token.Pos, format string, args ...interface{}) {
+	r.Report(analysis.Diagnostic{
+		Pos:      pos,
+		End:      end,
+		Category: CategoryManual,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// plumbing tracks the propagation of a context from a single root (a TODO or
+// a transitive call) up through the call graph.
+//
+// Functions are visited breadth-first so that each one is reached at its
+// shallowest depth, regardless of how many paths lead to it.
+type plumbing struct {
+	seen    map[types.Object]bool
+	queue   []plumbTarget
+	added   bool // set if a function gained a ctx parameter
+	blocked bool // set if a function can't be given a context, so no fix is suggested
+
+	// Since a blocked plumbing suggests no fix, the changes it makes to the diagnostic
+	// state are rolled back (see commit), so that later ones still make its edits.
+	decls   []*ast.FuncDecl       // added to paramAdded
+	lits    []*ast.FuncLit        // added to litAdded
+	ifaces  []*types.Func         // added to ifaceAdded
+	params  []string              // files of the functions gaining a ctx parameter, for the summary
+	facts   []types.Object        // exported functions and methods that need a context
+	renames []analysis.Diagnostic // reported before the plumbing itself
+	plan    int                   // length of r.plan when it started
+	imports int                   // length of r.imported when it started
+	summary int                   // length of r.summary.importing when it started
+}
+
+type plumbTarget struct {
+	decl  *ast.FuncDecl
+	depth int // the function containing the root is at depth 1
+}
+
+func (r *runner) newPlumbing() *plumbing {
+	return &plumbing{
+		seen:    map[types.Object]bool{},
+		plan:    len(r.plan),
+		imports: len(r.imported),
+		summary: len(r.summary.importing),
+	}
+}
+
+// commit keeps the changes made by p, reporting what it couldn't report as it went,
+// or rolls them back if it is blocked.  It must be called before reporting p's diagnostic.
+func (r *runner) commit(p *plumbing) {
+	if !p.blocked {
+		r.summary.gaining = append(r.summary.gaining, p.params...)
+		for _, obj := range p.facts {
+			r.ExportObjectFact(obj, &NeedsContext{})
+		}
+		r.pending = append(r.pending, p.renames...)
+		return
+	}
+	for _, decl := range p.decls {
+		delete(r.paramAdded, decl)
+	}
+	for _, lit := range p.lits {
+		delete(r.litAdded, lit)
+	}
+	for _, meth := range p.ifaces {
+		delete(r.ifaceAdded, meth)
+	}
+	for _, file := range r.imported[p.imports:] {
+		delete(r.contextImported, file)
+	}
+	r.imported = r.imported[:p.imports]
+	r.summary.importing = r.summary.importing[:p.summary]
+	r.plan = r.plan[:p.plan]
+}
+
+// fixes returns the suggested fix for plumbing with the given edits, unless it is blocked.
+func (p *plumbing) fixes(edits []analysis.TextEdit) []analysis.SuggestedFix {
+	if p.blocked {
+		return nil
+	}
+	return []analysis.SuggestedFix{
+		{
+			Message:   "Plumb context.Context",
+			TextEdits: edits,
+		},
+	}
+}
+
+// category returns the category for the diagnostic of this plumbing: CategoryManual if it
+// is blocked, CategorySubstitute if no function gained a ctx parameter, and otherwise the given one.
+func (p *plumbing) category(plumbed string) string {
+	switch {
+	case p.blocked:
+		return CategoryManual
+	case !p.added:
+		return CategorySubstitute
+	default:
+		return plumbed
+	}
+}
+
+func (p *plumbing) enqueue(decl *ast.FuncDecl, depth int) {
+	if decl == nil {
+		return
+	}
+	p.queue = append(p.queue, plumbTarget{decl, depth})
+}
+
+type localCall struct {
+	path   astPath         // declaration of the enclosing function
+	call   *ast.CallExpr   // call expression of the called function
+	assign *ast.AssignStmt // if present, the "ctx :=" assignment for the call
+	stmt   *ast.ExprStmt   // if present, the statement consisting solely of the call
+	paren  *ast.ParenExpr  // if present, the (redundant) parentheses around the call
+
+	background bool // if set, the call is to context.Background instead of context.TODO
+}
+
+func (r *runner) walkFuncDecl(decl *ast.FuncDecl) bool {
+	obj := r.TypesInfo.ObjectOf(decl.Name).(*types.Func)
+	if obj == nil {
+		r.logger.Infof("insufficient types to analyze %q", decl.Name)
+		return true
+	}
+	r.byObj[obj] = decl
+	if decl.Recv != nil {
+		r.methodDecls = append(r.methodDecls, decl)
+	}
+	return true
+}
+
+// walkTypeSpec records the methods of interfaces declared in the package.
+func (r *runner) walkTypeSpec(spec *ast.TypeSpec) bool {
+	iface, ok := spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return true
+	}
+	if named, ok := r.TypesInfo.TypeOf(spec.Name).(*types.Named); !ok || named.TypeParams().Len() > 0 {
+		return true // generic interfaces are not supported
+	}
+	for _, field := range iface.Methods.List {
+		if len(field.Names) == 0 {
+			continue // embedded interface
+		}
+		if meth, ok := r.TypesInfo.Defs[field.Names[0]].(*types.Func); ok {
+			r.ifaceMethods[meth] = field
+			r.ifaceMethodList = append(r.ifaceMethodList, meth)
+		}
+	}
+	return true
+}
+
+func (r *runner) walkAssignStmt(stack []ast.Node, assign *ast.AssignStmt) bool {
+	// Looking for: ctx := context.TODO()
+	//
+	// In any other assignment (like "ctx, cancel := context.WithCancel(context.TODO())"),
+	// only the call is replaced, so that the other variables it declares are kept.
+	if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return true
+	}
+
+	// Looking for: "ctx :=" or "_ ="
+	ident, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok {
+		return true
+	}
+	switch ident.Name {
+	case ContextName, "_":
+		// these are fine to replace
+	default:
+		// otherwise this isn't an assignment we want to touch
+		return true
+	}
+
+	// Looking for: ":= context.TODO()"
+	call, ok := assign.Rhs[0].(*ast.CallExpr)
+	if !ok {
+		return true
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return true
+	}
+	obj := r.TypesInfo.ObjectOf(sel.Sel)
+	background := IncludeBackground && r.isContextBackground(obj)
+	if !r.isContextTODO(obj) && !background {
+		return true
+	}
+
+	r.todos = append(r.todos, localCall{
+		path:       forStack(stack),
+		call:       call,
+		assign:     assign,
+		background: background,
+	})
+	return false // don't double-walk the call expression if we found our assignment
+}
+
+func (r *runner) walkCallExpr(stack []ast.Node, call *ast.CallExpr) bool {
+	ident := r.calleeIdent(call)
+	if ident == nil {
+		return true // who knows what this is, keep walking
+	}
+
+	r.called[ident] = true
+	called := r.TypesInfo.ObjectOf(ident)
+	if called == nil {
+		return true // no type info for called function
+	}
+	if v, ok := called.(*types.Var); ok {
+		// Calls through a variable (like "f := obj.Method; f()") are linked up
+		// once we know what the variable holds.
+		r.indirect[v] = append(r.indirect[v], localCall{
+			path: forStack(stack),
+			call: call,
+		})
+		return true
+	}
+	if fun, ok := called.(*types.Func); ok {
+		// Methods of instantiated generic types are distinct objects from the declared
+		// method, so make sure we're always talking about the one we have a decl for.
+		called = fun.Origin()
+	}
+
+	// Check if this is a call to context.TODO
+	//
+	// This is the case regardless of where the call appears (e.g. as an argument to a
+	// call into another package, a field in a composite literal, or the receiver of a
+	// method call like context.TODO().Value(k)), and only the TODO call itself will be
+	// rewritten.
+	if r.isContextTODO(called) {
+		r.todos = append(r.todos, localCall{
+			path:  forStack(stack),
+			call:  call,
+			stmt:  exprStmt(stack),
+			paren: parenExpr(stack),
+		})
+		return false // we're done here
+	}
+
+	// Check if this is a call to context.Background that we can replace
+	if IncludeBackground && r.isContextBackground(called) {
+		r.todos = append(r.todos, localCall{
+			path:       forStack(stack),
+			call:       call,
+			stmt:       exprStmt(stack),
+			paren:      parenExpr(stack),
+			background: true,
+		})
+		return false // we're done here
+	}
+
+	// Check if this is a call to something in this package
+	if r.isLocal(called.Pkg()) {
+		r.callers[called] = append(r.callers[called], localCall{
+			path: forStack(stack),
+			call: call,
+		})
+	}
+
+	// Check if this is a func for which we added a context to a call from another package
+	if !NoCrossPackage && r.ImportObjectFact(called, new(NeedsContext)) {
+		r.transitives = append(r.transitives, localCall{
+			path: forStack(stack),
+			call: call,
+		})
+	}
+
+	return true // keep walking in case there's something deeper in the AST (e.g. arguments to this call)
+}
+
+// walkResults records the named results of a function, so that assignments to them can be tracked.
+func (r *runner) walkResults(typ *ast.FuncType) {
+	if typ.Results == nil {
+		return
+	}
+	for _, field := range typ.Results.List {
+		for _, name := range field.Names {
+			if v, ok := r.TypesInfo.Defs[name].(*types.Var); ok {
+				r.results[v] = nil
+			}
+		}
+	}
+}
+
+// walkResultAssigns records the end of assign for each named result it assigns.
+func (r *runner) walkResultAssigns(assign *ast.AssignStmt) {
+	for _, expr := range assign.Lhs {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		v, ok := r.TypesInfo.Uses[ident].(*types.Var)
+		if !ok {
+			continue
+		}
+		if ends, ok := r.results[v]; ok {
+			r.results[v] = append(ends, assign.End())
+		}
+	}
+}
+
+// isAssigned returns false for a named result that hasn't been assigned before the
+// given position, since its zero value (like a nil context.Context) can't provide a context.
+func (r *runner) isAssigned(v *types.Var, at token.Pos) bool {
+	ends, ok := r.results[v]
+	if !ok {
+		return true
+	}
+	for _, end := range ends {
+		if end <= at {
+			return true
+		}
+	}
+	return false
+}
+
+// A funcValue is a local function (or method value) stored in a variable.
+type funcValue struct {
+	fun   types.Object
+	ident *ast.Ident // the use of the function in the variable's initializer
+
+	reassigned bool // if set, the variable can hold other values too
+}
+
+// walkFuncValues records variables that are initialized with a function value (e.g. "f := obj.Method")
+// so that calls through them can be linked into the call graph.
+func (r *runner) walkFuncValues(lhs, rhs []ast.Expr, define bool) {
+	for i, expr := range lhs {
+		ident, ok := expr.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		v, ok := r.TypesInfo.ObjectOf(ident).(*types.Var)
+		if !ok {
+			continue
+		}
+		if _, ok := v.Type().Underlying().(*types.Signature); !ok {
+			continue
+		}
+		fv, seen := r.funcVars[v]
+		if seen || !define || len(lhs) != len(rhs) {
+			fv.reassigned = true
+			r.funcVars[v] = fv
+			continue
+		}
+		var fun *ast.Ident
+		switch value := rhs[i].(type) {
+		case *ast.Ident:
+			fun = value
+		case *ast.SelectorExpr:
+			fun = value.Sel
+		}
+		if fun == nil {
+			continue
+		}
+		if obj, ok := r.TypesInfo.ObjectOf(fun).(*types.Func); ok && r.isLocal(obj.Pkg()) {
+			r.funcVars[v] = funcValue{fun: obj.Origin(), ident: fun}
+		}
+	}
+}
+
+// resolveFuncValues links calls through variables holding a single local function
+// into the call graph, and takes note of any other uses of local functions as values.
+func (r *runner) resolveFuncValues() {
+	followed := map[*ast.Ident]bool{}
+	for v, calls := range r.indirect {
+		fv, ok := r.funcVars[v]
+		if !ok || fv.reassigned || fv.fun == nil {
+			continue // who knows what this is
+		}
+		followed[fv.ident] = true
+		r.callers[fv.fun] = append(r.callers[fv.fun], calls...)
+	}
+	for ident, obj := range r.TypesInfo.Uses {
+		fun, ok := obj.(*types.Func)
+		if !ok || !r.isLocal(fun.Pkg()) || r.called[ident] || followed[ident] {
+			continue
+		}
+		r.values[fun.Origin()] = append(r.values[fun.Origin()], ident)
+	}
+	for _, idents := range r.values {
+		sort.Slice(idents, func(i, j int) bool { return idents[i].Pos() < idents[j].Pos() })
+	}
+	for _, calls := range r.callers {
+		sort.SliceStable(calls, func(i, j int) bool { return calls[i].call.Pos() < calls[j].call.Pos() })
+	}
+}
+
+func identExprs(idents []*ast.Ident) []ast.Expr {
+	exprs := make([]ast.Expr, len(idents))
+	for i, ident := range idents {
+		exprs[i] = ident
+	}
+	return exprs
+}
+
+func (r *runner) rewriteTODO(todo localCall) {
+	if FixMode == FixBackground {
+		if !todo.background {
+			r.replaceWithBackground(todo)
+		}
+		return
+	}
+	if !todo.background && todo.path.decl() == nil {
+		r.rewritePackageLevelTODO(todo)
+		return
+	}
+	if decl := contextHelper(todo); decl != nil {
+		if _, ok := r.hasContextProviderInPath(todo.path, todo.call.Pos()); !ok {
+			// Adding a ctx parameter just to return it would leave a helper that does nothing,
+			// so leave it up to the author to replace its calls with their own context.
+			r.pending = append(r.pending, analysis.Diagnostic{
+				Pos:      todo.call.Pos(),
+				End:      todo.call.End(),
+				Category: CategoryManual,
+				Message:  fmt.Sprintf("%s only returns context.TODO(), its callers should use their own context directly", decl.Name.Name),
+			})
+			return
+		}
+	}
+
+	p := r.newPlumbing()
+
+	var edits []analysis.TextEdit
+	if todo.background {
+		// A context.Background() is only replaced if we have a real context to use,
+		// otherwise it is already the best we can do.
+		expr, ok := r.hasContextProviderInPath(todo.path, todo.call.Pos())
+		if !ok {
+			return
+		}
+		if todo.stmt != nil {
+			edits = append(edits, r.editToRemoveStmt(todo.stmt))
+		} else {
+			edits = append(edits, r.editToReplaceCall(todo, expr))
+		}
+	} else if todo.stmt != nil {
+		// A "context.TODO()" statement discards the context, so there is nothing to plumb
+		// (and replacing it with "ctx" would be an unused expression), so it is just removed.
+		edits = append(edits, r.editToRemoveStmt(todo.stmt))
+	} else if owner := initOwner(todo.path, todo.assign); todo.assign != nil && owner != nil {
+		// An "if ctx := context.TODO(); ..." (or a for loop) can use a context available before the statement,
+		// but if that would be "ctx := ctx" (or we're adding the parameter) we can just remove it.
+		if expr, ok := r.hasContextProviderInPath(todo.path, owner.Pos()); ok && expr != ContextName {
+			edits = append(edits, r.editToReplaceCall(todo, expr))
+		} else {
+			if !ok {
+				p.enqueue(todo.path.decl(), 1)
+			}
+			edits = append(edits, r.editToRemoveInit(owner))
+		}
+	} else if todo.assign != nil {
+		if expr, ok := r.hasContextProviderInPath(todo.path, todo.assign.Pos()); ok && expr != ContextName {
+			// If there's a context under a different name (e.g. a "reqCtx" parameter), assign that
+			edits = append(edits, r.editToReplaceCall(todo, expr))
+		} else {
+			// If this is an assignment of the ctx parameter, we can just remove it
+			p.enqueue(todo.path.decl(), 1)
+			edits = append(edits, r.editToRemoveStmt(todo.assign))
+		}
+	} else if expr, ok := r.hasContextProviderInPath(todo.path, todo.call.Pos()); ok {
+		// If we have a way to get the parameter, we can use that
+		edits = append(edits, r.editToReplaceCall(todo, expr))
+	} else {
+		// Otherwise, since we're adding the ctx parameter to this function,
+		// we also need to update the call that we're rewriting to "ctx".
+		p.enqueue(todo.path.decl(), 1)
+		edits = append(edits, r.editToReplaceCall(todo, ContextName))
+	}
+	edits = append(edits, r.plumb(p)...)
+	if FixComments && !todo.background {
+		edits = append(edits, r.editsToRemoveStaleComments(todo)...)
+	}
+
+	message := r.limitFiles(p, edits, "Plumb context")
+	r.commit(p)
+	fixes := p.fixes(edits)
+	r.pending = append(r.pending, analysis.Diagnostic{
+		Pos:            todo.call.Pos(),
+		End:            todo.call.End(),
+		Category:       p.category(CategoryPlumb),
+		Message:        message + r.reach(fixes),
+		SuggestedFixes: fixes,
+	})
+}
+
+// reach returns a note of how many functions and files the edits of fixes span, for AnnotateReach.
+func (r *runner) reach(fixes []analysis.SuggestedFix) string {
+	if !AnnotateReach || len(fixes) == 0 {
+		return ""
+	}
+	files, funcs := map[string]bool{}, map[*ast.FuncDecl]bool{}
+	for _, fix := range fixes {
+		for _, te := range fix.TextEdits {
+			files[filename(r.Fset, te.Pos)] = true
+			if decl := r.funcDeclAt(te.Pos); decl != nil {
+				funcs[decl] = true
+			}
+		}
+	}
+	return fmt.Sprintf(" (reach: %s, %s)", plural(len(funcs), "function"), plural(len(files), "file"))
+}
+
+// plural returns the count of n of the given noun, like "1 file" or "2 files".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// funcDeclAt returns the function declaration containing pos, if there is one.
+func (r *runner) funcDeclAt(pos token.Pos) *ast.FuncDecl {
+	file := r.file(pos)
+	if file == nil {
+		return nil
+	}
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.FuncDecl); ok && decl.Pos() <= pos && pos < decl.End() {
+			return decl
+		}
+	}
+	return nil
+}
+
+// contextHelper returns the enclosing function declaration if its body consists
+// solely of returning the context.TODO() call.
+func contextHelper(todo localCall) *ast.FuncDecl {
+	if todo.background || todo.assign != nil {
+		return nil
+	}
+	path, last := todo.path.pop()
+	if last == todo.call {
+		path, last = path.pop()
+	}
+	ret, ok := last.(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 || len(path) < 2 {
+		return nil
+	}
+	path, body := path.pop()
+	_, decl := path.pop()
+	if fd, ok := decl.(*ast.FuncDecl); ok && fd.Body == body && len(fd.Body.List) == 1 {
+		return fd
+	}
+	return nil
+}
+
+// replaceWithBackground replaces a context.TODO() with context.Background(), for FixBackground.
+func (r *runner) replaceWithBackground(todo localCall) {
+	expr := r.contextRef(todo.call.Pos(), "Background") + "()"
+	edits := []analysis.TextEdit{r.editToReplaceCall(todo, expr)}
+	edits = append(edits, r.editToImportContext(todo.call.Pos())...)
+	r.pending = append(r.pending, analysis.Diagnostic{
+		Pos:      todo.call.Pos(),
+		End:      todo.call.End(),
+		Category: CategorySubstitute,
+		Message:  "Replace context.TODO()",
+		SuggestedFixes: []analysis.SuggestedFix{
+			{
+				Message:   "Replace with " + expr,
+				TextEdits: edits,
+			},
+		},
+	})
+}
+
+// rewritePackageLevelTODO handles a context.TODO() outside of any function declaration
+// (e.g. "var defaultCtx = context.TODO()"), where there's no parameter to add.  Since
+// package initialization isn't part of any request, context.Background() is suggested.
+func (r *runner) rewritePackageLevelTODO(todo localCall) {
+	message := "Plumb context"
+	expr, ok := r.hasContextProviderInPath(todo.path, todo.call.Pos())
+	if lit, callers, trackable := r.trackableFuncLit(todo.path); !ok && trackable {
+		// A function literal whose calls we know about can gain a parameter instead
+		p := r.newPlumbing()
+		edits := []analysis.TextEdit{r.editToReplaceCall(todo, ContextName)}
+		edits = append(edits, r.addContextParamToFuncLit(lit, callers, p)...)
+		edits = append(edits, r.plumb(p)...)
+		message = r.limitFiles(p, edits, message)
+		r.commit(p)
+		fixes := p.fixes(edits)
+		r.pending = append(r.pending, analysis.Diagnostic{
+			Pos:            todo.call.Pos(),
+			End:            todo.call.End(),
+			Category:       p.category(CategoryPlumb),
+			Message:        message + r.reach(fixes),
+			SuggestedFixes: fixes,
+		})
+		return
+	}
+	if !ok {
+		message = "Package-level context.TODO() has no context to plumb"
+		expr = r.contextRef(todo.call.Pos(), "Background") + "()"
+	}
+	r.pending = append(r.pending, analysis.Diagnostic{
+		Pos:      todo.call.Pos(),
+		End:      todo.call.End(),
+		Category: CategorySubstitute,
+		Message:  message,
+		SuggestedFixes: []analysis.SuggestedFix{
+			{
+				Message:   "Replace with " + expr,
+				TextEdits: []analysis.TextEdit{r.editToReplaceCall(todo, expr)},
+			},
+		},
+	})
+}
+
+// trackableFuncLit returns the innermost function literal in path, if all of its calls
+// are known: either it is called immediately, or it is assigned to a variable that is
+// only ever called.
+func (r *runner) trackableFuncLit(path astPath) (lit *ast.FuncLit, callers []localCall, ok bool) {
+	i := len(path) - 1
+	for ; i > 0; i-- {
+		if lit, ok = path[i].(*ast.FuncLit); ok {
+			break
+		}
+	}
+	if lit == nil || i == 0 {
+		return nil, nil, false
+	}
+
+	var lhs []*ast.Ident
+	var rhs []ast.Expr
+	switch parent := path[i-1].(type) {
+	case *ast.CallExpr:
+		if parent.Fun == lit {
+			return lit, []localCall{{path: path[:i], call: parent}}, true
+		}
+		return nil, nil, false
+	case *ast.ValueSpec:
+		lhs, rhs = parent.Names, parent.Values
+	case *ast.AssignStmt:
+		for _, expr := range parent.Lhs {
+			ident, _ := expr.(*ast.Ident)
+			lhs = append(lhs, ident)
+		}
+		rhs = parent.Rhs
+	}
+	if len(lhs) != len(rhs) {
+		return nil, nil, false
+	}
+	for j, value := range rhs {
+		if value != lit || lhs[j] == nil {
+			continue
+		}
+		v, ok := r.TypesInfo.ObjectOf(lhs[j]).(*types.Var)
+		if !ok || r.funcVars[v].reassigned {
+			return nil, nil, false
+		}
+		uses := 0
+		for _, obj := range r.TypesInfo.Uses {
+			if obj == v {
+				uses++
+			}
+		}
+		if uses != len(r.indirect[v]) {
+			return nil, nil, false // it is used as a value somewhere
+		}
+		return lit, r.indirect[v], true
+	}
+	return nil, nil, false
+}
+
+// addContextParamToFuncLit adds a ctx parameter to lit, and propagates the context to its callers.
+func (r *runner) addContextParamToFuncLit(lit *ast.FuncLit, callers []localCall, p *plumbing) (edits []analysis.TextEdit) {
+	if r.litAdded[lit] {
+		return nil
+	}
+	r.litAdded[lit] = true
+	p.lits = append(p.lits, lit)
+	p.params = append(p.params, filename(r.Fset, lit.Pos()))
+	p.added = true
+
+	edits = append(edits, r.editToPrependCtxParam(lit.Pos(), "func literal", lit.Type.Params))
+	edits = append(edits, r.editToImportContext(lit.Pos())...)
+	for _, caller := range callers {
+		edits = append(edits, r.propagateContextForCall(caller, p, 2)...)
+	}
+	return edits
+}
+
+func (r *runner) rewriteTransitives(todo localCall) {
+	p := r.newPlumbing()
+	edits := r.propagateContextForCall(todo, p, 1)
+	edits = append(edits, r.plumb(p)...)
+	message := r.limitFiles(p, edits, "Continue plumbing context")
+	r.commit(p)
+	r.pending = append(r.pending, analysis.Diagnostic{
+		Pos:            todo.call.Pos(),
+		End:            todo.call.End(),
+		Category:       p.category(CategoryTransitive),
+		Message:        message,
+		SuggestedFixes: p.fixes(edits),
+	})
+}
+
+// limitFiles blocks the plumbing if its edits span more than MaxFiles files, returning
+// the message to report for it: the given one, or why there is no fix.
+func (r *runner) limitFiles(p *plumbing, edits []analysis.TextEdit, message string) string {
+	if MaxFiles <= 0 || p.blocked {
+		return message
+	}
+	files := map[string]bool{}
+	for _, te := range edits {
+		files[filename(r.Fset, te.Pos)] = true
+	}
+	if len(files) <= MaxFiles {
+		return message
+	}
+	p.blocked = true
+	return fmt.Sprintf("Not plumbing context: it would edit %d files (more than --max-files %d), so it should be done manually", len(files), MaxFiles)
+}
+
+// plumb drains the queue of functions that need a context, returning all of the
+// edits necessary to provide one to each of them.
+func (r *runner) plumb(p *plumbing) (edits []analysis.TextEdit) {
+	for len(p.queue) > 0 {
+		next := p.queue[0]
+		p.queue = p.queue[1:]
+		edits = append(edits, r.propagateContextThrough(next.decl, p, next.depth)...)
+	}
+	return edits
+}
+
+func (r *runner) propagateContextThrough(funcDecl *ast.FuncDecl, p *plumbing, depth int) (edits []analysis.TextEdit) {
+	if funcDecl == nil {
+		return nil
+	}
+
+	fun := r.TypesInfo.ObjectOf(funcDecl.Name).(*types.Func)
+	if p.seen[fun] {
+		return
+	}
+	p.seen[fun] = true
+
+	if r.bodyless(funcDecl, p) {
+		return
+	}
+
+	// Check if the function declares a variable named ctx that isn't a context.
+	//
+	// If it does, declaring ctx ourselves would collide with it (or be shadowed by it),
+	// so nothing along this path is fixed.
+	if local := r.nonContextLocal(funcDecl); local != nil {
+		if !r.collided[funcDecl] {
+			r.collided[funcDecl] = true
+			r.manualf(local.Pos(), token.NoPos, "Not adding context to %s, it declares a non-context %s", fun.Name(), ContextName)
+		}
+		p.blocked = true
+		return
+	}
+
+	// Make sure a different diagnostic didn't add a context parameter already
+	if r.paramAdded[funcDecl] {
+		return
+	}
+	r.paramAdded[funcDecl] = true
+	p.decls = append(p.decls, funcDecl)
+
+	// Check if the function has a ctx parameter that isn't a context.
+	//
+	// If it does, it's in the way of the one we're adding, so it has to be renamed.
+	// That is suggested by a diagnostic of its own, which is reported first.
+	renamed := r.nonContextParam(funcDecl)
+	if renamed != nil {
+		p.renames = append(p.renames, r.nonContextParamDiagnostic(funcDecl, renamed))
+	}
+
+	// Check if the function itself has a ctx parameter.
+	//
+	// If it does, we don't need to propagate the context because it already has one.
+	params := fun.Type().(*types.Signature).Params()
+	for i, n := 0, params.Len(); i < n; i++ {
+		param := params.At(i)
+		if param.Name() == ContextName && param != r.TypesInfo.Defs[renamed] {
+			// Call already has a "ctx" parameter.
+			return
+		}
+	}
+
+	// Check if the function has any parameters that can provide a context (e.g. http.Request,
+	// or a context.Context named something other than ctx) and declare ctx from it.
+	//
+	// This comes first so that test functions can use (*testing.T).Context.
+	if expr, ok := preferDirect(func(direct bool) (string, bool) {
+		return r.hasContextProviderParam(fun, token.NoPos, direct)
+	}); ok {
+		edits = append(edits, r.editToAddContextVarDecl(funcDecl, expr))
+		return
+	}
+
+	// Check if the function is main, a top-level test function, or another entry point.
+	//
+	// If it is, then we can't add ctx, so we'll just stop.
+	if r.isRoot(funcDecl) {
+		edits = append(edits, r.editToAddRootContext(funcDecl)...)
+		return
+	}
+
+	// Check if we have gone far enough up the call graph.
+	//
+	// If we have, then we don't add ctx and just use the RootContext like a root.
+	if MaxDepth > 0 && depth > MaxDepth {
+		r.logger.Infof("Not adding context to %s: beyond maxdepth %d", fun.FullName(), MaxDepth)
+		edits = append(edits, r.editToAddRootContext(funcDecl)...)
+		return
+	}
+
+	// Check if the function is used as a value (e.g. passed as a callback).
+	//
+	// If it is, its signature is fixed by whatever it's passed to, so we use the RootContext like a root.
+	if uses := r.values[fun]; len(uses) > 0 {
+		for _, use := range uses {
+			r.manualf(use.Pos(), token.NoPos, "Not adding context to %s, its signature is fixed by this use", fun.Name())
+		}
+		edits = append(edits, r.editToAddRootContext(funcDecl)...)
+		return
+	}
+
+	// Check if the function is exported when we're only plumbing within the package.
+	//
+	// If it is, changing its signature would break other packages, so we use the RootContext like a root.
+	if NoCrossPackage && fun.Exported() {
+		r.manualf(funcDecl.Name.Pos(), token.NoPos, "Not adding context to exported %s (--no-cross-package)", fun.Name())
+		edits = append(edits, r.editToAddRootContext(funcDecl)...)
+		return
+	}
+
+	return r.addContextParam(funcDecl, p, depth)
+}
+
+// nonContextParam returns the name of the parameter of funcDecl named ContextName,
+// if there is one and its type isn't context.Context.
+func (r *runner) nonContextParam(funcDecl *ast.FuncDecl) *ast.Ident {
+	for _, field := range funcDecl.Type.Params.List {
+		for _, name := range field.Names {
+			if name.Name == ContextName && !r.isContextContext(r.TypesInfo.Defs[name].Type()) {
+				return name
+			}
+		}
+	}
+	return nil
+}
+
+// nonContextParamDiagnostic returns a diagnostic that the parameter name of funcDecl has to be
+// renamed to make room for a ctx parameter, suggesting a fix that renames it and its uses.
+func (r *runner) nonContextParamDiagnostic(funcDecl *ast.FuncDecl, name *ast.Ident) analysis.Diagnostic {
+	param := r.TypesInfo.Defs[name]
+	newName := r.paramName(funcDecl, param.Type())
+	var edits []analysis.TextEdit
+	ast.Inspect(funcDecl, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && r.TypesInfo.ObjectOf(id) == param {
+			edits = append(edits, r.editToRenameIdent(id, newName))
+		}
+		return true
+	})
+	return analysis.Diagnostic{
+		Pos:      name.Pos(),
+		End:      name.End(),
+		Category: CategoryManual,
+		Message:  fmt.Sprintf("Non-context %s parameter (of type %s) has to be renamed for %s to gain a context", ContextName, types.TypeString(param.Type(), types.RelativeTo(r.Pkg)), funcDecl.Name.Name),
+		SuggestedFixes: []analysis.SuggestedFix{
+			{
+				Message:   fmt.Sprintf("Rename %s to %s", ContextName, newName),
+				TextEdits: edits,
+			},
+		},
+	}
+}
+
+// unusedName returns base, or base with a number added if that is already used in fn.
+func unusedName(fn ast.Node, base string) string {
+	used := map[string]bool{}
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			used[id.Name] = true
+		}
+		return true
+	})
+	name := base
+	for n := 1; used[name]; n++ {
+		name = fmt.Sprintf("%s%d", base, n)
+	}
+	return name
+}
+
+// addContextParam adds a ctx parameter to funcDecl, and propagates the context to its callers.
+func (r *runner) addContextParam(funcDecl *ast.FuncDecl, p *plumbing, depth int) (edits []analysis.TextEdit) {
+	fun := r.TypesInfo.ObjectOf(funcDecl.Name).(*types.Func)
+	if r.bodyless(funcDecl, p) {
+		return
+	}
+	r.logger.Infof("Adding context to %s", fun.FullName())
+	p.params = append(p.params, filename(r.Fset, funcDecl.Pos()))
+	p.added = true
+
+	// If it is an exported function, allow other packages to understand the context is being added,
+	// unless a shim keeps its signature for them
+	shim, shimmed := r.shimName(funcDecl)
+	if fun.Exported() && !NoCrossPackage && !shimmed {
+		p.facts = append(p.facts, fun)
+	}
+
+	// Add the parameter
+	edits = append(edits, r.editToPrependCtxParam(funcDecl.Name.Pos(), funcDecl.Name.Name, funcDecl.Type.Params))
+	edits = append(edits, r.editToImportContext(funcDecl.Name.Pos())...)
+	edits = append(edits, r.editsToAssignCtx(funcDecl)...)
+	if shimmed {
+		edits = append(edits, r.editsToAddShim(funcDecl, shim)...)
+	}
+
+	// Uses of the function as a value (e.g. passed as a callback) can't be updated,
+	// which can only happen here if an interface it implements is gaining a context.
+	for _, use := range r.values[fun] {
+		r.manualf(use.Pos(), token.NoPos, "Cannot plumb context through this use of %s", fun.Name())
+	}
+
+	for _, caller := range r.callers[r.TypesInfo.ObjectOf(funcDecl.Name)] {
+		edits = append(edits, r.propagateContextForCall(caller, p, depth+1)...)
+		if shimmed {
+			edits = append(edits, r.editToRenameIdent(caller.call.Fun.(*ast.Ident), shim))
+		}
+	}
+
+	// If this is a method that implements local interfaces, they need a context too
+	for _, meth := range r.implementedMethods(fun) {
+		edits = append(edits, r.propagateContextThroughInterface(meth, p, depth)...)
+	}
+
+	return
+}
+
+// bodyless reports whether funcDecl has no body (like a function implemented in assembly),
+// so it can't be given a context: nothing along the path of p is fixed.
+func (r *runner) bodyless(funcDecl *ast.FuncDecl, p *plumbing) bool {
+	if funcDecl.Body != nil {
+		return false
+	}
+	if !r.collided[funcDecl] {
+		r.collided[funcDecl] = true
+		r.manualf(funcDecl.Name.Pos(), token.NoPos, "Not adding context to %s, it has no body", funcDecl.Name.Name)
+	}
+	p.blocked = true
+	return true
+}
+
+// nonContextLocal returns a variable named ContextName declared in the body of funcDecl
+// (including in function literals) whose type isn't context.Context, if there is one.
+func (r *runner) nonContextLocal(funcDecl *ast.FuncDecl) (local types.Object) {
+	if funcDecl.Body == nil {
+		return nil
+	}
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || id.Name != ContextName {
+			return local == nil
+		}
+		if v, ok := r.TypesInfo.Defs[id].(*types.Var); ok && !r.isContextContext(v.Type()) {
+			local = v
+		}
+		return local == nil
+	})
+	return local
+}
+
+// propagateContextThroughInterface adds a ctx parameter to the interface method meth,
+// to all of the local methods implementing it, and to the calls made through it.
+func (r *runner) propagateContextThroughInterface(meth *types.Func, p *plumbing, depth int) (edits []analysis.TextEdit) {
+	if p.seen[meth] || r.ifaceAdded[meth] {
+		return
+	}
+	p.seen[meth] = true
+	r.ifaceAdded[meth] = true
+	p.ifaces = append(p.ifaces, meth)
+
+	field, ok := r.ifaceMethods[meth]
+	if !ok {
+		return
+	}
+	r.logger.Infof("Adding context to %s", meth.FullName())
+
+	// Calls through the interface in other packages will need a context too
+	if meth.Exported() && !NoCrossPackage {
+		p.facts = append(p.facts, meth)
+	}
+
+	edits = append(edits, r.editToPrependCtxParam(field.Names[0].Pos(), meth.Name(), field.Type.(*ast.FuncType).Params))
+	edits = append(edits, r.editToImportContext(field.Pos())...)
+
+	// Every other implementation has to change to keep satisfying the interface
+	for _, impl := range r.implementations(meth) {
+		if p.seen[impl] {
+			continue
+		}
+		p.seen[impl] = true
+		decl := r.byObj[impl]
+		if r.paramAdded[decl] || r.hasContextParam(impl) {
+			continue
+		}
+		r.paramAdded[decl] = true
+		p.decls = append(p.decls, decl)
+		edits = append(edits, r.addContextParam(decl, p, depth)...)
+	}
+
+	for _, caller := range r.callers[meth] {
+		edits = append(edits, r.propagateContextForCall(caller, p, depth+1)...)
+	}
+	return
+}
+
+// hasContextParam returns true if fun has a parameter named ContextName.
+func (r *runner) hasContextParam(fun *types.Func) bool {
+	params := fun.Type().(*types.Signature).Params()
+	for i, n := 0, params.Len(); i < n; i++ {
+		if params.At(i).Name() == ContextName {
+			return true
+		}
+	}
+	return false
+}
+
+// implementedMethods returns the methods of the package's interfaces that are implemented by the method fun.
+func (r *runner) implementedMethods(fun *types.Func) (methods []*types.Func) {
+	recv := fun.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return nil
+	}
+	for _, meth := range r.ifaceMethodList {
+		if meth.Name() != fun.Name() {
+			continue
+		}
+		if r.implements(recv.Type(), meth) {
+			methods = append(methods, meth)
+		}
+	}
+	return methods
+}
+
+// implementations returns the local methods (with declarations) that implement the interface method meth.
+func (r *runner) implementations(meth *types.Func) (impls []*types.Func) {
+	for _, decl := range r.methodDecls {
+		fun := r.TypesInfo.ObjectOf(decl.Name).(*types.Func)
+		if fun.Name() != meth.Name() {
+			continue
+		}
+		if r.implements(fun.Type().(*types.Signature).Recv().Type(), meth) {
+			impls = append(impls, fun)
+		}
+	}
+	return impls
+}
+
+// implements returns true if the receiver type recv (or a pointer to it) implements
+// the interface containing meth.
+func (r *runner) implements(recv types.Type, meth *types.Func) bool {
+	iface, ok := meth.Type().(*types.Signature).Recv().Type().Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	return types.Implements(recv, iface) || types.Implements(types.NewPointer(recv), iface)
+}
+
+// propagateContextForCall adds a context to the given call, queueing the calling
+// function (at the given depth) if it needs to be provided a context as well.
+func (r *runner) propagateContextForCall(caller localCall, p *plumbing, depth int) (edits []analysis.TextEdit) {
+	if expr, ok := r.hasContextProviderInPath(caller.path, caller.call.Pos()); ok {
+		// There is already a way to get "ctx" in the current scope, call it and move on
+		edits = append(edits, r.editToPrependExpr(caller.call, expr))
+		return
+	}
+
+	if caller.path.decl() == nil {
+		// Calls at package level (e.g. in a variable initializer) can only use the root context
+		expr, usesContext := r.rootContext(caller.call.Pos())
+		edits = append(edits, r.editToPrependExpr(caller.call, expr))
+		if usesContext {
+			edits = append(edits, r.editToImportContext(caller.call.Pos())...)
+		}
+		return
+	}
+
+	// Ensure that the calling function itself has a ctx parameter to pass
+	p.enqueue(caller.path.decl(), depth)
+
+	// Add the new "ctx" parameter to call-sites
+	edits = append(edits, r.editToPrependExpr(caller.call, ContextName))
+	return
+}
+
+// isRoot returns true if funcDecl can't gain a ctx parameter because it is called
+// from outside of the program: main, init, top-level tests, and Entrypoints.
+func (r *runner) isRoot(funcDecl *ast.FuncDecl) bool {
+	fun, ok := r.TypesInfo.ObjectOf(funcDecl.Name).(*types.Func)
+	if !ok {
+		return false
+	}
+	return r.isMainOrInit(fun) || r.isTopLevelTestFunc(funcDecl) || r.isEntrypoint(fun)
+}
+
+func (r *runner) isMainOrInit(fun *types.Func) bool {
+	if fun.Pkg().Name() == "main" && fun.Name() == "main" {
+		return true
+	}
+	if fun.Name() == "init" && fun.Type().(*types.Signature).Recv() == (*types.Var)(nil) {
+		return true
+	}
+	return false
+}
+
+func (r *runner) isTopLevelTestFunc(funcDecl *ast.FuncDecl) bool {
+	return strings.HasSuffix(filename(r.Fset, funcDecl.Pos()), "_test.go") && topLevelTestFunc.MatchString(funcDecl.Name.Name)
+}
+
+// funcName returns the name of fun, or "Type.Method" for a method, as matched by
+// Entrypoints and ProviderFuncs.
+func funcName(fun *types.Func) string {
+	name := fun.Name()
+	if recv := fun.Type().(*types.Signature).Recv(); recv != nil {
+		typ := recv.Type()
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		if named, ok := typ.(*types.Named); ok {
+			name = named.Obj().Name() + "." + name
+		}
+	}
+	return name
+}
+
+// isEntrypoint returns true if the name of fun (or "Type.Method" for a method) matches one of Entrypoints.
+func (r *runner) isEntrypoint(fun *types.Func) bool {
+	name := funcName(fun)
+	for _, re := range r.entrypoints {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// preferDirect calls find for direct contexts, and then for contexts obtained via a Context() method.
+func preferDirect(find func(direct bool) (string, bool)) (string, bool) {
+	for _, direct := range []bool{true, false} {
+		if expr, ok := find(direct); ok {
+			return expr, true
+		}
+	}
+	return "", false
+}
+
+// hasContextProviderInPath looks for a context that is available at the given position,
+// starting with the innermost function.  Within each function, a context.Context is
+// preferred over a value with a Context() method.
+func (r *runner) hasContextProviderInPath(caller astPath, at token.Pos) (string, bool) {
+	return r.contextProviderInPath(caller, at, false, false)
+}
+
+// contextProviderInPath implements hasContextProviderInPath.
+//
+// Once the walk leaves a go or defer statement, the statement can run after the
+// enclosing scope has moved on (and e.g. cancelled a local context or changed a
+// loop variable), so only parameters are considered from there outward.  The
+// exception is the arguments of a deferred call (like "defer f(context.TODO())"),
+// which are evaluated by the defer statement, so anything in scope there can be used.
+//
+// Similarly, once the walk leaves a function literal that isn't called immediately
+// (like one that is stored to be called later), it escapes the loop iteration it was
+// created in, so nothing declared by an enclosing loop is considered.
+func (r *runner) contextProviderInPath(caller astPath, at token.Pos, detached, escaped bool) (string, bool) {
+	if len(caller) == 0 {
+		return "", false
+	}
+	prev, last := caller.pop()
+	switch last := last.(type) {
+	case *ast.GoStmt:
+		detached, escaped = true, true
+	case *ast.DeferStmt:
+		if inFuncLit(last.Call, at) {
+			detached, escaped = true, true
+		} else {
+			at = last.Pos()
+		}
+	case *ast.AssignStmt:
+		// When we walk out of an assignment, update the "at" position because anything within
+		// the assignment can't consider anything declared inside it.
+		//
+		// Variables declared by any form of assignment (e.g. "ctx, err := setup()" or a range
+		// clause) are found by the scope lookup, since they're visible after it.
+		at = last.Pos()
+	case *ast.RangeStmt:
+		// Likewise, the range expression is evaluated before the loop starts, so it can't
+		// use the iteration variables or anything declared in the loop body.
+		if escaped || last.X.Pos() <= at && at < last.X.End() {
+			at = last.Pos()
+		}
+	case *ast.ForStmt:
+		if escaped {
+			at = last.Pos()
+		}
+	case *ast.FuncDecl:
+		fun := r.TypesInfo.ObjectOf(last.Name).(*types.Func)
+		if expr, ok := preferDirect(func(direct bool) (string, bool) {
+			// Check contexts declared in the body first, then formal parameters
+			if expr, ok := r.hasContextInBody(r.TypesInfo.Scopes[last.Type], last.Body, at, direct && !detached); ok {
+				return expr, true
+			}
+			if expr, ok := r.hasContextProviderParam(fun, at, direct); ok {
+				return expr, true
+			}
+			if detached {
+				return "", false
+			}
+			// Check variables that are in scope
+			return r.hasContextProviderInScope(r.TypesInfo.Scopes[last.Type], at, direct)
+		}); ok {
+			return expr, true
+		}
+	case *ast.FuncLit: // TODO block
+		if expr, ok := preferDirect(func(direct bool) (string, bool) {
+			// Check contexts declared in the body first, then formal parameters
+			if expr, ok := r.hasContextInBody(r.TypesInfo.Scopes[last.Type], last.Body, at, direct && !detached); ok {
+				return expr, true
+			}
+			if expr, ok := r.providedContext(prev, last, direct); ok {
+				return expr, true
+			}
+			if expr, ok := r.hasContextProviderField(last, last.Type.Params, r.TypesInfo.Scopes[last.Type], at, direct); ok {
+				return expr, true
+			}
+			if detached {
+				return "", false
+			}
+			// Check variables that are in scope
+			return r.hasContextProviderInScope(r.TypesInfo.Scopes[last.Type], at, direct)
+		}); ok {
+			return expr, true
+		}
+		// A function literal that isn't called right away can outlive the loop iteration
+		if _, parent := prev.pop(); !isCallOf(parent, last) {
+			escaped = true
+		}
+	}
+	return r.contextProviderInPath(prev, at, detached, escaped)
+}
+
+// contextExpr returns the expression for obtaining a context from a value with the given
+// name and type, either directly (if it is a context.Context) or via its Context() method.
+func (r *runner) contextExpr(name string, typ types.Type, direct bool) (string, bool) {
+	if direct && (r.isContextContext(typ) || r.embedsContext(typ)) {
+		return name, true
+	}
+	if method, ok := r.contextMethod(typ); !direct && ok {
+		return name + "." + method + "()", true
+	}
+	return "", false
+}
+
+// embedsContext returns true if typ is a struct (or a pointer to one) that directly embeds
+// a context.Context and implements it through the promoted methods, so that a value of
+// typ can be used as a context itself.
+//
+// If another embedded field also promotes one of the context methods, the selectors
+// are ambiguous and the struct doesn't implement context.Context, so it isn't used.
+func (r *runner) embedsContext(typ types.Type) bool {
+	elem := typ
+	if ptr, ok := typ.(*types.Pointer); ok {
+		elem = ptr.Elem()
+	}
+	st, ok := elem.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Embedded() || !r.isContextContext(field.Type()) {
+			continue
+		}
+		iface := field.Type().Underlying().(*types.Interface)
+		return types.Implements(typ, iface)
+	}
+	return false
+}
+
+// hasContextProviderParam looks for a parameter of fun that can provide a context
+// (directly, or via a Context() method), followed by its receiver if it is a method.
+//
+// If at is valid, parameters that are shadowed at that position are ignored.
+func (r *runner) hasContextProviderParam(fun *types.Func, at token.Pos, direct bool) (expr string, ok bool) {
+	if decl, ok := r.byObj[fun]; ok {
+		if expr, ok := r.hasContextProviderField(decl, decl.Type.Params, fun.Scope(), at, direct); ok {
+			return expr, true
+		}
+	}
+	if recv := fun.Type().(*types.Signature).Recv(); recv != nil {
+		if recv.Name() == "" || recv.Name() == "_" {
+			if _, ok := r.contextExpr("recv", recv.Type(), direct); ok && !r.unnamedRecv[recv] {
+				r.unnamedRecv[recv] = true
+				r.manualf(recv.Pos(), token.NoPos, "Name this receiver if you want plumber to use it")
+			}
+			return "", false
+		}
+		if at.IsValid() && !r.isVisible(fun.Scope(), recv, at) {
+			return "", false
+		}
+		return r.contextExpr(recv.Name(), recv.Type(), direct)
+	}
+	return "", false
+}
+
+// hasContextProviderField looks for a parameter in fields (of fn, whose function has
+// the given scope) that can provide a context without being shadowed at the given position.
+//
+// If the parameters are unnamed, the one that can provide a context is named (see nameParams).
+func (r *runner) hasContextProviderField(fn ast.Node, fields *ast.FieldList, scope *types.Scope, at token.Pos, direct bool) (expr string, ok bool) {
+	for i, field := range fields.List {
+		tav, ok := r.TypesInfo.Types[field.Type]
+		if !ok {
+			continue
+		}
+		if len(field.Names) == 0 {
+			if _, ok := r.contextExpr("_", tav.Type, direct); !ok {
+				continue
+			}
+			if name := r.nameParams(fn, fields, i, tav.Type); name != "" {
+				return r.contextExpr(name, tav.Type, direct)
+			}
+			continue
+		}
+		for _, ident := range field.Names {
+			if ident.Name == "_" {
+				continue
+			}
+			if obj := r.TypesInfo.Defs[ident]; obj != nil && !r.isVisible(scope, obj, at) {
+				continue
+			}
+			if expr, ok := r.contextExpr(ident.Name, tav.Type, direct); ok {
+				return expr, true
+			}
+		}
+	}
+	if DeepProviderSearch {
+		for _, field := range fields.List {
+			for _, ident := range field.Names {
+				obj, ok := r.TypesInfo.Defs[ident].(*types.Var)
+				if !ok || ident.Name == "_" || !r.isVisible(scope, obj, at) {
+					continue
+				}
+				if expr, ok := r.fieldContextExpr(ident.Name, obj.Type(), direct); ok {
+					return expr, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// fieldContextExpr returns the expression for obtaining a context from a field of a
+// value with the given name and struct type (or pointer to one), for DeepProviderSearch.
+//
+// Only the fields of the struct itself are considered, so the expression has a
+// single selector (like "req.Ctx" or "env.Request.Context()").
+func (r *runner) fieldContextExpr(name string, typ types.Type, direct bool) (string, bool) {
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return "", false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if field.Embedded() || field.Name() == "_" {
+			continue // embedded fields promote their methods, which contextExpr already found
+		}
+		if !field.Exported() && field.Pkg() != r.Pkg {
+			continue
+		}
+		if expr, ok := r.contextExpr(name+"."+field.Name(), field.Type(), direct); ok {
+			return expr, true
+		}
+	}
+	return "", false
+}
+
+// nameParams suggests a fix naming the unnamed parameters in fields (of fn), so that
+// the one at index i can be used, returning its name.  Since either all parameters are
+// named or none are, the others are named "_".
+//
+// The names are chosen once for each parameter list, so if a different parameter was
+// chosen to be named first, the one at index i is named "_" and nameParams returns "".
+func (r *runner) nameParams(fn ast.Node, fields *ast.FieldList, i int, typ types.Type) string {
+	if names, ok := r.paramNames[fields]; ok {
+		if names[i] == "_" {
+			return ""
+		}
+		return names[i]
+	}
+
+	names := make([]string, len(fields.List))
+	var edits []analysis.TextEdit
+	for j, field := range fields.List {
+		names[j] = "_"
+		if j == i {
+			names[j] = r.paramName(fn, typ)
+		}
+		edits = append(edits, analysis.TextEdit{
+			Pos:     field.Type.Pos(),
+			End:     field.Type.Pos(),
+			NewText: []byte(names[j] + " "),
+		})
+	}
+	r.paramNames[fields] = names
+	r.planned(edits[i], fields.List[i].Pos(), planOther, "name parameter %s", names[i])
+
+	field := fields.List[i]
+	r.pending = append(r.pending, analysis.Diagnostic{
+		Pos:      field.Pos(),
+		End:      field.End(),
+		Category: CategorySubstitute,
+		Message:  "Name this param if you want plumber to use it",
+		SuggestedFixes: []analysis.SuggestedFix{
+			{
+				Message:   "Name the parameter " + names[i],
+				TextEdits: edits,
+			},
+		},
+	})
+	return names[i]
+}
+
+// splitParamName splits an entry of ParamNames into its type and name.
+func splitParamName(entry string) (typ, name string) {
+	eq := strings.LastIndex(entry, "=")
+	if eq < 0 {
+		return "", ""
+	}
+	return strings.TrimSpace(entry[:eq]), strings.TrimSpace(entry[eq+1:])
+}
+
+// paramName chooses the name for an unnamed (or renamed) parameter of fn with the given type:
+// the name from ParamNames for its type, ContextName for a context.Context, or else
+// the first letter of its type's name (like "r" for *http.Request).  A number is
+// added if the name is already used in fn.
+func (r *runner) paramName(fn ast.Node, typ types.Type) string {
+	typeName := types.TypeString(typ, func(pkg *types.Package) string { return pkg.Name() })
+	base := ""
+	for _, entry := range ParamNames {
+		if t, name := splitParamName(entry); t == typeName {
+			base = name
+		}
+	}
+	if base == "" && r.isContextContext(typ) {
+		base = ContextName
+	}
+	if base == "" {
+		base = "p"
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		if named, ok := typ.(*types.Named); ok {
+			base = strings.ToLower(named.Obj().Name()[:1])
+		}
+	}
+
+	return unusedName(fn, base)
+}
+
+// hasContextProviderInScope looks for a variable that can provide a context at
+// the given position, starting with the innermost scope that contains it and
+// working outward to (and including) the given function scope.
+func (r *runner) hasContextProviderInScope(scope *types.Scope, at token.Pos, direct bool) (expr string, ok bool) {
+	if scope == nil {
+		return "", false
+	}
+	inner := scope.Innermost(at)
+	if inner == nil {
+		inner = scope
+	}
+	for s := inner; s != nil; s = s.Parent() {
+		for _, p := range r.scopeProviders(s, direct) {
+			if r.isVisible(inner, p.v, at) && r.isAssigned(p.v, at) {
+				return p.expr, true
+			}
+		}
+		if s == scope {
+			break
+		}
+	}
+	return "", false
+}
+
+// hasContextInBody looks for a context.Context declared in the body of the function
+// with the given scope and body (not one of its parameters) that is visible at the given position.
+//
+// Such a context (like the ctx of "g, ctx := errgroup.WithContext(parent)") is usually
+// derived from the parameters, so it is preferred over them.  Only direct lookups are done.
+func (r *runner) hasContextInBody(scope *types.Scope, body *ast.BlockStmt, at token.Pos, direct bool) (string, bool) {
+	if scope == nil || body == nil || !direct {
+		return "", false
+	}
+	inner := scope.Innermost(at)
+	if inner == nil {
+		inner = scope
+	}
+	for s := inner; s != nil; s = s.Parent() {
+		for _, p := range r.scopeProviders(s, true) {
+			if body.Pos() <= p.v.Pos() && r.isVisible(inner, p.v, at) {
+				return p.expr, true
+			}
+		}
+		if s == scope {
+			break
+		}
+	}
+	return "", false
+}
+
+// scopeProvider is a variable that can provide a context, and the expression for doing so.
+type scopeProvider struct {
+	v    *types.Var
+	expr string
+}
+
+// scopeLookup is the key for memoizing scopeProviders.
+type scopeLookup struct {
+	scope  *types.Scope
+	direct bool
+}
+
+// scopeProviders returns the variables declared in scope that can provide a context (directly or not),
+// in the order they should be preferred.
+//
+// The same scopes are searched many times while plumbing through a large package (once for
+// each call that needs a context), so the results are memoized.
+func (r *runner) scopeProviders(scope *types.Scope, direct bool) []scopeProvider {
+	key := scopeLookup{scope, direct}
+	if providers, ok := r.providers[key]; ok {
+		return providers
+	}
+	var providers []scopeProvider
+	for _, varname := range scope.Names() {
+		v, ok := scope.Lookup(varname).(*types.Var)
+		if !ok {
+			continue
+		}
+		if expr, ok := r.contextExpr(v.Name(), v.Type(), direct); ok {
+			providers = append(providers, scopeProvider{v, expr})
+		}
+	}
+	r.providers[key] = providers
+	return providers
+}
+
+// isVisible returns true if obj is declared and not shadowed at the given position within scope.
+func (r *runner) isVisible(scope *types.Scope, obj types.Object, at token.Pos) bool {
+	if scope == nil {
+		return true // nothing to check against
+	}
+	if inner := scope.Innermost(at); inner != nil {
+		scope = inner
+	}
+	_, found := scope.LookupParent(obj.Name(), at)
+	return found == obj
+}
+
+// contextMethod returns the name of a method of typ (Context, or one of ContextMethods)
+// that can be called to obtain a context.
+func (r *runner) contextMethod(typ types.Type) (string, bool) {
+	for _, name := range append([]string{"Context"}, ContextMethods...) {
+		// This finds promoted methods (like (*testing.T).Context) as well as methods
+		// required by interfaces and type parameter constraints.
+		obj, _, _ := types.LookupFieldOrMethod(typ, true, r.Pkg, name)
+		if meth, ok := obj.(*types.Func); ok && r.isContextMethod(meth) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// isContextMethod returns true if meth takes no arguments and returns only a context.Context.
+func (r *runner) isContextMethod(meth *types.Func) bool {
+	sig := meth.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	return r.isContextContext(sig.Results().At(0).Type())
+}
+
+// editToReplaceCall replaces only the span of the call of todo (e.g. a context.TODO() argument)
+// with expr, so it works wherever the argument appears in the enclosing call.
+//
+// Any expression wrapping the call (like a conversion or the type assertion in
+// "context.TODO().(T)") is left as it is: the expressions used to replace it are all
+// identifiers, selectors, or calls, so they bind the same way without parentheses.
+// For the same reason, parentheses directly around the call (like "(context.TODO()).Done()")
+// are replaced along with it.
+func (r *runner) editToReplaceCall(todo localCall, expr string) analysis.TextEdit {
+	var replaced ast.Expr = todo.call
+	if todo.paren != nil {
+		replaced = todo.paren
+	}
+	edit := analysis.TextEdit{
+		Pos:     replaced.Pos(),
+		End:     replaced.End(),
+		NewText: []byte(expr),
+	}
+	r.planned(edit, replaced.Pos(), planOther, "replace %s with %s", types.ExprString(replaced), expr)
+	return edit
+}
+
+func (r *runner) editToAddContextVarDecl(funcDecl *ast.FuncDecl, call string) analysis.TextEdit {
+	body := funcDecl.Body
+	stmt := ContextName + " := " + call
+
+	var text string
+	switch {
+	case len(body.List) > 0 && r.line(body.List[0].Pos()) != r.line(body.Lbrace):
+		// Put it on its own line before the first statement
+		text = "\n" + r.indentAt(body.List[0].Pos()) + stmt
+	case len(body.List) > 0:
+		// Keep one-line bodies on one line: { ctx := x; stmt }
+		text = " " + stmt + ";"
+	case r.line(body.Lbrace) != r.line(body.Rbrace):
+		// Empty body spanning multiple lines
+		text = "\n" + r.indentAt(body.Lbrace) + "\t" + stmt
+	default:
+		// Empty body: {}
+		text = " " + stmt + " "
+	}
+	edit := analysis.TextEdit{
+		Pos:     body.Lbrace + 1,
+		End:     body.Lbrace + 1,
+		NewText: []byte(text),
+	}
+	r.planned(edit, funcDecl.Name.Pos(), planOther, "declare %s in %s", stmt, funcDecl.Name.Name)
+	return edit
+}
+
+// editToAddRootContext declares a ctx variable in funcDecl using the RootContext,
+// importing the context package if the expression refers to it.
+func (r *runner) editToAddRootContext(funcDecl *ast.FuncDecl) (edits []analysis.TextEdit) {
+	expr, usesContext := r.rootContext(funcDecl.Pos())
+	edits = append(edits, r.editToAddContextVarDecl(funcDecl, expr))
+	if usesContext {
+		edits = append(edits, r.editToImportContext(funcDecl.Name.Pos())...)
+	}
+	return edits
+}
+
+// rootContext returns the RootContext expression for use in the file containing pos,
+// and whether it refers to the context package.
+func (r *runner) rootContext(pos token.Pos) (expr string, usesContext bool) {
+	if RootContext == defaultRootContext {
+		// Respect any alias for the context package
+		return r.contextRef(pos, "Background") + "()", true
+	}
+
+	parsed, err := parser.ParseExpr(RootContext)
+	if err != nil {
+		return RootContext, false // checked in run
+	}
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == r.contextPackageName(ContextImportPaths[0]) {
+				usesContext = true
+			}
+		}
+		return !usesContext
+	})
+	return RootContext, usesContext
+}
+
+// editsToAssignCtx turns the top-level "ctx := ..." statements in the body of funcDecl
+// (like "ctx := context.WithValue(ctx, k, v)") into assignments, since they would
+// otherwise redeclare the ctx parameter it is gaining.
+//
+// Statements declaring other variables too (like "ctx, cancel := ...") are still valid,
+// and "ctx := context.TODO()" statements are removed instead.
+func (r *runner) editsToAssignCtx(funcDecl *ast.FuncDecl) (edits []analysis.TextEdit) {
+	removed := map[*ast.AssignStmt]bool{}
+	for _, todo := range r.todos {
+		removed[todo.assign] = true
+	}
+	for _, stmt := range funcDecl.Body.List {
+		assign, ok := stmt.(*ast.AssignStmt)
+		if !ok || assign.Tok != token.DEFINE || removed[assign] {
+			continue
+		}
+		var ctx types.Object
+		others := false
+		for _, lhs := range assign.Lhs {
+			ident, ok := lhs.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if obj := r.TypesInfo.Defs[ident]; obj != nil && ident.Name == ContextName {
+				ctx = obj
+			} else if obj != nil {
+				others = true
+			}
+		}
+		if ctx == nil || others || !r.isContextContext(ctx.Type()) {
+			continue
+		}
+		edit := analysis.TextEdit{
+			Pos:     assign.TokPos,
+			End:     assign.TokPos + token.Pos(len(token.DEFINE.String())),
+			NewText: []byte(token.ASSIGN.String()),
+		}
+		r.planned(edit, assign.TokPos, planOther, "assign %s instead of declaring it", ContextName)
+		edits = append(edits, edit)
+	}
+	return edits
+}
+
+// editToPrependCtxParam adds a ctx parameter to the params of the named function (whose name is at pos).
+//
+// With --ctx-position=last it is added at the end instead, unless the function is variadic.
+func (r *runner) editToPrependCtxParam(pos token.Pos, name string, params *ast.FieldList) (edit analysis.TextEdit) {
+	defer func() { r.planned(edit, pos, planParam, "add %s parameter to %s", ContextName, name) }()
+	item := ContextName + " " + r.contextRef(pos, "Context")
+	if n := len(params.List); CtxPosition == PositionLast {
+		if n == 0 || !isEllipsis(params.List[n-1].Type) {
+			var last ast.Node
+			if n > 0 {
+				last = params.List[n-1]
+			}
+			return r.editToAppendListItem(params.Closing, last, item, ParamComment)
+		}
+		r.manualf(pos, token.NoPos, "Adding %s as the first parameter of variadic %s", ContextName, name)
+	}
+	var first ast.Node
+	if len(params.List) > 0 {
+		first = params.List[0]
+	}
+	return r.editToPrependListItem(params.Opening, first, item, ParamComment)
+}
+
+// isEllipsis returns true if typ is the "...T" type of a variadic parameter.
+func isEllipsis(typ ast.Expr) bool {
+	_, ok := typ.(*ast.Ellipsis)
+	return ok
+}
+
+// editToPrependExpr passes varname as the first argument to callExpr, which is only
+// appropriate for calls to functions that gained a leading ctx parameter.
+//
+// For method expressions (like "(*T).Method(obj)"), it is passed after the receiver.
+// With --ctx-position=last it is passed last instead, unless the function is variadic.
+func (r *runner) editToPrependExpr(callExpr *ast.CallExpr, varname string) (edit analysis.TextEdit) {
+	defer func() {
+		r.planned(edit, callExpr.Lparen, planCall, "pass %s to %s", varname, types.ExprString(callExpr.Fun))
+	}()
+	if sig, ok := r.TypesInfo.TypeOf(callExpr.Fun).(*types.Signature); ok && CtxPosition == PositionLast && !sig.Variadic() {
+		var last ast.Node
+		if n := len(callExpr.Args); n > 0 {
+			last = callExpr.Args[n-1]
+		}
+		return r.editToAppendListItem(callExpr.Rparen, last, varname, "")
+	}
+	if r.isMethodExpr(callExpr.Fun) && len(callExpr.Args) > 0 {
+		recv := callExpr.Args[0]
+		text := ", " + varname
+		if r.line(recv.Pos()) != r.line(callExpr.Lparen) {
+			text = ",\n" + r.indentAt(recv.Pos()) + varname
+		}
+		return analysis.TextEdit{
+			Pos:     recv.End(),
+			End:     recv.End(),
+			NewText: []byte(text),
+		}
+	}
+	var first ast.Node
+	if len(callExpr.Args) > 0 {
+		first = callExpr.Args[0]
+	}
+	return r.editToPrependListItem(callExpr.Lparen, first, varname, "")
+}
+
+// isMethodExpr returns true if fun is a method expression like "T.Method".
+func (r *runner) isMethodExpr(fun ast.Expr) bool {
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	selection, ok := r.TypesInfo.Selections[sel]
+	return ok && selection.Kind() == types.MethodExpr
+}
+
+// editToPrependListItem inserts item at the beginning of the parenthesized list
+// opened at lparen, whose first element (if any) is first.
+//
+// If comment is not empty, it is added after the item: as a line comment if the
+// item gets its own line, and as a block comment otherwise.
+func (r *runner) editToPrependListItem(lparen token.Pos, first ast.Node, item, comment string) analysis.TextEdit {
+	multiline := first != nil && r.line(first.Pos()) != r.line(lparen)
+	if comment != "" && !multiline {
+		item += " /* " + comment + " */"
+	}
+	var text string
+	switch {
+	case first == nil:
+		text = item
+	case multiline:
+		// One element per line, so the new one gets its own line too
+		text = "\n" + r.indentAt(first.Pos()) + item + ","
+		if comment != "" {
+			text += " // " + comment
+		}
+	default:
+		text = item + ", "
+	}
+	return analysis.TextEdit{
+		Pos:     lparen + 1,
+		End:     lparen + 1,
+		NewText: []byte(text),
+	}
+}
+
+// editToAppendListItem inserts item at the end of the parenthesized list closed
+// at rparen, whose last element (if any) is last.  The comment is added like
+// editToPrependListItem does.
+func (r *runner) editToAppendListItem(rparen token.Pos, last ast.Node, item, comment string) analysis.TextEdit {
+	if last != nil && r.line(last.End()) != r.line(rparen) {
+		// One element per line, so the new one gets its own line before the closing paren
+		text := r.indentAt(last.Pos()) + item + ","
+		if comment != "" {
+			text += " // " + comment
+		}
+		lineStart := rparen - token.Pos(r.Fset.Position(rparen).Column-1)
+		return analysis.TextEdit{
+			Pos:     lineStart,
+			End:     lineStart,
+			NewText: []byte(text + "\n"),
+		}
+	}
+	if comment != "" {
+		item += " /* " + comment + " */"
+	}
+	pos, text := rparen, item
+	if last != nil {
+		pos, text = last.End(), ", "+item
+	}
+	return analysis.TextEdit{
+		Pos:     pos,
+		End:     pos,
+		NewText: []byte(text),
+	}
+}
+
+// editToRenameIdent replaces ident with name.
+func (r *runner) editToRenameIdent(ident *ast.Ident, name string) analysis.TextEdit {
+	return analysis.TextEdit{
+		Pos:     ident.Pos(),
+		End:     ident.End(),
+		NewText: []byte(name),
+	}
+}
+
+// editToRemoveInit removes the init statement (and its semicolon) from an if, switch, or for statement.
+//
+// A for statement keeps both of its semicolons if it has a post statement, and otherwise
+// loses them so that it reads "for cond {" (or "for {") like gofmt would write it.
+func (r *runner) editToRemoveInit(owner ast.Stmt) analysis.TextEdit {
+	var init ast.Stmt
+	var next token.Pos
+	var text []byte
+	switch owner := owner.(type) {
+	case *ast.IfStmt:
+		init, next = owner.Init, owner.Cond.Pos()
+	case *ast.SwitchStmt:
+		init, next = owner.Init, owner.Body.Lbrace
+		if owner.Tag != nil {
+			next = owner.Tag.Pos()
+		}
+	case *ast.TypeSwitchStmt:
+		init, next = owner.Init, owner.Assign.Pos()
+	case *ast.ForStmt:
+		init, next = owner.Init, owner.Init.End()
+		if src := r.source(owner.Pos()); owner.Post == nil && src != nil {
+			next = owner.Body.Lbrace
+			if owner.Cond != nil {
+				start, end := r.Fset.Position(owner.Cond.Pos()).Offset, r.Fset.Position(owner.Cond.End()).Offset
+				text = append(append(text, src[start:end]...), ' ')
+			}
+		}
+	}
+	edit := analysis.TextEdit{Pos: init.Pos(), End: next, NewText: text}
+	r.planned(edit, init.Pos(), planOther, "remove statement")
+	return edit
+}
+
+// editToRemoveStmt removes the statement, along with its line if nothing else is on it.
+func (r *runner) editToRemoveStmt(stmt ast.Stmt) analysis.TextEdit {
+	edit := r.editToRemoveNode(stmt)
+	r.planned(edit, stmt.Pos(), planOther, "remove statement")
+	return edit
+}
+
+// editsToRemoveStaleComments removes the comments directly above the statement containing
+// todo that match the FixCommentsPattern, since the fix makes them out of date.
+func (r *runner) editsToRemoveStaleComments(todo localCall) (edits []analysis.TextEdit) {
+	file := r.file(todo.call.Pos())
+	if file == nil {
+		return nil
+	}
+	// The comment is attached to the outermost statement starting on the same line as the
+	// innermost one, e.g. the if statement for "if ctx := context.TODO(); ...".
+	var stmt ast.Stmt
+	for i := len(todo.path) - 1; i >= 0; i-- {
+		s, ok := todo.path[i].(ast.Stmt)
+		if _, block := s.(*ast.BlockStmt); !ok || block {
+			continue
+		}
+		if stmt != nil && r.line(s.Pos()) != r.line(stmt.Pos()) {
+			break
+		}
+		stmt = s
+	}
+	if stmt == nil {
+		return nil
+	}
+
+	cmap, ok := r.commentMaps[file]
+	if !ok {
+		cmap = ast.NewCommentMap(r.Fset, file, file.Comments)
+		r.commentMaps[file] = cmap
+	}
+	for _, group := range cmap[stmt] {
+		// Only comments ending on the line above the statement are about it;
+		// trailing comments (and ones separated by a blank line) are left alone.
+		if r.line(group.End())+1 != r.line(stmt.Pos()) || !r.staleComment.MatchString(group.Text()) {
+			continue
+		}
+		edit := r.editToRemoveNode(group)
+		r.planned(edit, group.Pos(), planOther, "remove comment")
+		edits = append(edits, edit)
+	}
+	return edits
+}
+
+// editToRemoveNode removes the source of node, along with its line if nothing else is on it.
+func (r *runner) editToRemoveNode(node ast.Node) analysis.TextEdit {
+	src := r.source(node.Pos())
+	start, end := r.Fset.Position(node.Pos()).Offset, r.Fset.Position(node.End()).Offset
+	if src == nil || end > len(src) {
+		return analysis.TextEdit{Pos: node.Pos(), End: node.End()}
+	}
+
+	before, after := start, end
+	for before > 0 && isHorizontalSpace(src[before-1]) {
+		before--
+	}
+	for after < len(src) && isHorizontalSpace(src[after]) {
+		after++
+	}
+
+	edit := analysis.TextEdit{Pos: node.Pos(), End: node.End()}
+	switch {
+	case (before == 0 || src[before-1] == '\n') && (after == len(src) || src[after] == '\n'):
+		// The node is alone on its line, so remove the whole line
+		edit.Pos -= token.Pos(start - before)
+		edit.End += token.Pos(after - end)
+		if after < len(src) {
+			edit.End++
+		}
+	case before > 0 && src[before-1] == '{' && after < len(src) && src[after] == '}':
+		// The node is alone in a one-line block, so leave it empty: {}
+		edit.Pos -= token.Pos(start - before)
+		edit.End += token.Pos(after - end)
+	case after < len(src) && src[after] != ';':
+		// Something (like a comment) follows the node, so it takes its place
+		edit.End += token.Pos(after - end)
+	}
+	return edit
+}
+
+func isHorizontalSpace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
+
+// source returns the contents of the file containing pos.
+func (r *runner) source(pos token.Pos) []byte {
+	filename := filename(r.Fset, pos)
+	if src, ok := r.sources[filename]; ok {
+		return src
+	}
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		r.logger.Warnf("failed to read %q: %s", filename, err)
+	}
+	r.sources[filename] = src
+	return src
+}
+
+// line returns the line number of pos.
+func (r *runner) line(pos token.Pos) int {
+	return r.Fset.Position(pos).Line
+}
+
+// indentAt returns the leading whitespace of the line containing pos.
+func (r *runner) indentAt(pos token.Pos) string {
+	src := r.source(pos)
+	offset := r.Fset.Position(pos).Offset
+	if offset > len(src) {
+		return ""
+	}
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	end := start
+	for end < offset && isHorizontalSpace(src[end]) {
+		end++
+	}
+	return string(src[start:end])
+}
+
+func (r *runner) editToImportContext(pos token.Pos) []analysis.TextEdit {
+	filename := filename(r.Fset, pos)
+	file := r.file(pos)
+	if file == nil {
+		r.logger.Warnf("failed to find file %q to add context import", filename)
+		return nil
+	}
+
+	if r.contextImported[file] {
+		return nil
+	}
+	r.contextImported[file] = true
+	r.imported = append(r.imported, file)
+
+	if _, ok := contextImport(file); ok {
+		return nil
+	}
+	r.summary.importing = append(r.summary.importing, filename)
+
+	var importBlock *ast.GenDecl
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok.String() == "import" && decl.Lparen.IsValid() {
+				importBlock = decl
+				break
+			}
+		}
+	}
+
+	// If we found an import block, add it in sorted order
+	if importBlock != nil {
+		r.logger.Infof("Adding import to %q", filepath.Base(filename))
+		pos, text := r.importInsertion(importBlock, ContextImportPaths[0])
+		edit := analysis.TextEdit{
+			Pos:     pos,
+			End:     pos,
+			NewText: []byte(text),
+		}
+		r.planned(edit, importBlock.Pos(), planImport, "import context")
+		return []analysis.TextEdit{edit}
+	}
+	// Otherwise add a new declaration before the first one
+	if len(file.Decls) > 0 { // should always be true
+		r.logger.Infof("Adding import to %q (no import block found)", filepath.Base(filename))
+		first := file.Decls[0]
+		spec := "import " + strconv.Quote(ContextImportPaths[0])
+		pos, text := first.Pos(), spec+"\n\n"
+		switch first := first.(type) {
+		case *ast.GenDecl:
+			if first.Doc != nil {
+				pos = first.Doc.Pos()
+			}
+			if first.Tok == token.IMPORT {
+				text = spec + "\n" // keep the imports together
+			}
+		case *ast.FuncDecl:
+			if first.Doc != nil {
+				pos = first.Doc.Pos()
+			}
+		}
+		edit := analysis.TextEdit{
+			Pos:     pos,
+			End:     pos,
+			NewText: []byte(text),
+		}
+		r.planned(edit, first.Pos(), planImport, "import context")
+		return []analysis.TextEdit{edit}
+	}
+
+	r.logger.Warnf("unable to add import to %q", filepath.Base(filename))
+	return nil
+}
+
+// importInsertion returns where (and how) to insert an import of path into the import block,
+// following the conventions of goimports: the imports are grouped (with blank lines between
+// the groups) into the standard library and the rest, and are sorted within each group.
+//
+// The path goes in sorted order into the first group of its kind; if there isn't one, it gets
+// a new group, first for the standard library and last otherwise.
+func (r *runner) importInsertion(block *ast.GenDecl, path string) (token.Pos, string) {
+	spec := strconv.Quote(path)
+	line := func(pos token.Pos) int { return r.Fset.PositionFor(pos, false).Line }
+	start := func(imp *ast.ImportSpec) token.Pos {
+		if imp.Doc != nil {
+			return imp.Doc.Pos()
+		}
+		return imp.Pos()
+	}
+	end := func(imp *ast.ImportSpec) token.Pos {
+		if imp.Comment != nil {
+			return imp.Comment.End()
+		}
+		return imp.End()
+	}
+
+	// Split the imports into groups
+	var groups [][]*ast.ImportSpec
+	for i, s := range block.Specs {
+		imp := s.(*ast.ImportSpec)
+		if i == 0 || line(start(imp)) > line(end(block.Specs[i-1].(*ast.ImportSpec)))+1 {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], imp)
+	}
+
+	std := isStandardImport(path)
+	for _, group := range groups {
+		if p, err := strconv.Unquote(group[0].Path.Value); err != nil || isStandardImport(p) != std {
+			continue
+		}
+		for _, imp := range group {
+			if p, err := strconv.Unquote(imp.Path.Value); err == nil && p > path {
+				return start(imp), spec + "\n\t"
+			}
+		}
+		return end(group[len(group)-1]), "\n\t" + spec
+	}
+
+	switch {
+	case len(groups) == 0:
+		return block.Lparen + 1, "\n\t" + spec
+	case std:
+		return block.Lparen + 1, "\n\t" + spec + "\n"
+	default:
+		return end(block.Specs[len(block.Specs)-1].(*ast.ImportSpec)), "\n\n\t" + spec
+	}
+}
+
+// isStandardImport reports whether path is in the standard library, which (like goimports)
+// is assumed of paths whose first element has no dot.
+func isStandardImport(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// contextImport returns the import of a context package (one of the ContextImportPaths) in file,
+// if there is one that can be referred to (i.e. not a blank import).
+func contextImport(file *ast.File) (*ast.ImportSpec, bool) {
+	for _, imp := range file.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err != nil || !isContextPackage(path) {
+			continue
+		}
+		if imp.Name != nil && imp.Name.Name == "_" {
+			continue
+		}
+		return imp, true
+	}
+	return nil, false
+}
+
+// contextRef returns the text for referring to name from the context package in
+// the file containing pos, respecting any alias (or dot-import) of the package.
+func (r *runner) contextRef(pos token.Pos, name string) string {
+	file := r.file(pos)
+	if file == nil {
+		return r.contextPackageName(ContextImportPaths[0]) + "." + name
+	}
+	imp, ok := contextImport(file)
+	if !ok {
+		return r.contextPackageName(ContextImportPaths[0]) + "." + name // the import will be added if necessary
+	}
+	if imp.Name == nil {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		return r.contextPackageName(path) + "." + name
+	}
+	if imp.Name.Name == "." {
+		return name
+	}
+	return imp.Name.Name + "." + name
+}
+
+// contextPackageName returns the name of the context package with the given import path,
+// which (for packages this package doesn't import yet) is assumed to match the last element of the path.
+func (r *runner) contextPackageName(importPath string) string {
+	for _, imp := range r.Pkg.Imports() {
+		if imp.Path() == importPath {
+			return imp.Name()
+		}
+	}
+	return path.Base(importPath)
+}
+
+// file returns the file containing pos, if it is one of the files compiled into the package.
+//
+// Files excluded by build constraints are not included, even though they may be
+// in the same directory.
+func (r *runner) file(pos token.Pos) *ast.File {
+	return r.files[filename(r.Fset, pos)]
+}
+
+// filename returns the name of the file containing pos.  Unlike the Filename of its
+// Position, it ignores //line directives (like those in the output of goyacc), since
+// the file is what gets edited.
+func filename(fset *token.FileSet, pos token.Pos) string {
+	return fset.PositionFor(pos, false).Filename
+}
+
+func (r *runner) isLocal(pkg *types.Package) bool {
+	if pkg == nil {
+		return false
+	}
+	return r.Pkg.Path() == pkg.Path()
+}
+
+var topLevelTestFunc = regexp.MustCompile(`^(Test|Benchmark|Fuzz)[A-Z]`)
+
+type astPath []ast.Node
+
+// initOwner returns the if, switch, or for statement for which stmt (the last node in path)
+// is the init statement, if there is one.
+func initOwner(path astPath, stmt ast.Stmt) ast.Stmt {
+	if len(path) < 2 {
+		return nil
+	}
+	switch owner := path[len(path)-2].(type) {
+	case *ast.IfStmt:
+		if owner.Init == stmt {
+			return owner
+		}
+	case *ast.SwitchStmt:
+		if owner.Init == stmt {
+			return owner
+		}
+	case *ast.TypeSwitchStmt:
+		if owner.Init == stmt {
+			return owner
+		}
+	case *ast.ForStmt:
+		if owner.Init == stmt {
+			return owner
+		}
+	}
+	return nil
+}
+
+// exprStmt returns the statement containing the last node of stack if that node
+// is the whole statement (like a "context.TODO()" statement), or nil otherwise.
+func exprStmt(stack []ast.Node) *ast.ExprStmt {
+	if len(stack) < 2 {
+		return nil
+	}
+	stmt, _ := stack[len(stack)-2].(*ast.ExprStmt)
+	return stmt
+}
+
+// parenExpr returns the outermost of the parentheses directly around the last node in stack,
+// if there are any.
+func parenExpr(stack []ast.Node) (paren *ast.ParenExpr) {
+	for i := len(stack) - 2; i >= 0; i-- {
+		p, ok := stack[i].(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		paren = p
+	}
+	return paren
+}
+
+func forStack(stack []ast.Node) astPath {
+	return append([]ast.Node(nil), stack...)
+}
+
+func (p astPath) decl() (last *ast.FuncDecl) {
+	for _, n := range p {
+		if decl, ok := n.(*ast.FuncDecl); ok {
+			last = decl
+		}
+	}
+	return
+}
+
+// inFuncLit returns true if pos is within a function literal in n.
+func inFuncLit(n ast.Node, pos token.Pos) (found bool) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok && lit.Pos() <= pos && pos < lit.End() {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// calleeIdent returns the identifier naming the function or method that call calls
+// (like f in f(x), obj.f(x), or f[int](x)), or nil if it doesn't call one by name.
+func (r *runner) calleeIdent(call *ast.CallExpr) *ast.Ident {
+	fun := call.Fun
+	switch index := fun.(type) {
+	case *ast.IndexExpr:
+		fun = index.X
+	case *ast.IndexListExpr:
+		fun = index.X
+	}
+	var ident *ast.Ident
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	}
+	if ident != nil && fun != call.Fun {
+		// Only a generic function is indexed by its type arguments when it's called;
+		// other index expressions (like fns[i](x)) call an element of a value.
+		if _, ok := r.TypesInfo.ObjectOf(ident).(*types.Func); !ok {
+			return nil
+		}
+	}
+	return ident
+}
+
+// isCallOf returns true if n is a call of fun.
+func isCallOf(n ast.Node, fun ast.Expr) bool {
+	call, ok := n.(*ast.CallExpr)
+	return ok && call.Fun == fun
+}
+
+func (p astPath) pop() (astPath, ast.Node) {
+	n := len(p) - 1
+	return p[:n], p[n]
+}
+
+type planKind int
+
+const (
+	planOther planKind = iota
+	planParam
+	planCall
+	planImport
+)
+
+// A planItem describes a single edit for the DryRun plan.
+type planItem struct {
+	edit editKey // only listed if reportDiagnostics emits it
+	pos  token.Position
+	kind planKind
+	desc string
+}
+
+// An editKey identifies an edit by its span and its new text.
+type editKey struct {
+	pos, end token.Pos
+	text     string
+}
+
+func keyOf(te analysis.TextEdit) editKey {
+	end := te.End
+	if !end.IsValid() {
+		end = te.Pos
+	}
+	return editKey{te.Pos, end, string(te.NewText)}
+}
+
+// planned describes edit (at pos, which is where the plan lists it) for the DryRun plan.
+func (r *runner) planned(edit analysis.TextEdit, pos token.Pos, kind planKind, format string, args ...interface{}) {
+	if !DryRun {
+		return
+	}
+	r.plan = append(r.plan, planItem{
+		edit: keyOf(edit),
+		pos:  r.Fset.PositionFor(pos, false), // like the edits, which are to the file itself
+		kind: kind,
+		desc: fmt.Sprintf(format, args...),
+	})
+}
+
+// writePlan writes the planned edits that were emitted to Output, grouped by file.
+func (r *runner) writePlan() {
+	byFile := map[string][]planItem{}
+	var filenames []string
+	listed := map[editKey]bool{}
+	for _, item := range r.plan {
+		filename := item.pos.Filename
+		if strings.HasPrefix(filename, ModuleCache) {
+			continue // these edits will be ignored anyway
+		}
+		if !r.emitted[item.edit] || listed[item.edit] {
+			continue // dropped (e.g. from an excluded file), or already listed
+		}
+		listed[item.edit] = true
+		if _, ok := byFile[filename]; !ok {
+			filenames = append(filenames, filename)
+		}
+		byFile[filename] = append(byFile[filename], item)
+	}
+	sort.Strings(filenames)
+
+	buf := new(bytes.Buffer)
+	for _, filename := range filenames {
+		items := byFile[filename]
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].pos.Offset < items[j].pos.Offset
+		})
+
+		counts := map[planKind]int{}
+		for _, item := range items {
+			counts[item.kind]++
+		}
+		gain := "gain"
+		if counts[planParam] == 1 {
+			gain = "gains"
+		}
+		fmt.Fprintf(buf, "%s: %s %s a %s parameter, %s rewritten", filename, plural(counts[planParam], "function"), gain, ContextName, plural(counts[planCall], "call site"))
+		if counts[planImport] > 0 {
+			fmt.Fprintf(buf, ", context import added")
+		}
+		fmt.Fprintln(buf)
+		for _, item := range items {
+			fmt.Fprintf(buf, "\t%d:%d: %s\n", item.pos.Line, item.pos.Column, item.desc)
+		}
+	}
+
+	outMu.Lock()
+	defer outMu.Unlock()
+	if _, err := buf.WriteTo(Output); err != nil {
+		r.logger.Warnf("failed to write plan: %s", err)
+	}
+}
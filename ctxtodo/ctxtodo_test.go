@@ -0,0 +1,921 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestMain(m *testing.M) {
+	// Tests that use the cache give it a temporary directory, rather than the real one
+	CacheDir = ""
+	os.Exit(m.Run())
+}
+
+func Test(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, "./src/...")
+}
+
+// TestFormatting ensures that applying the suggested fixes produces the golden
+// files exactly, without needing to gofmt the result.
+func TestFormatting(t *testing.T) {
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, Analyzer, "./src/...")
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	edits := map[string][]edit{}
+	for _, result := range results {
+		fset := result.Pass.Fset
+		for _, diag := range result.Diagnostics {
+			for _, fix := range diag.SuggestedFixes {
+				for _, te := range fix.TextEdits {
+					start, end := fset.PositionFor(te.Pos, false), fset.PositionFor(te.Pos, false)
+					if te.End.IsValid() {
+						end = fset.PositionFor(te.End, false)
+					}
+					edits[start.Filename] = append(edits[start.Filename], edit{start.Offset, end.Offset, string(te.NewText)})
+				}
+			}
+		}
+	}
+
+	for filename, edits := range edits {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("reading source: %s", err)
+		}
+		want, err := os.ReadFile(filename + ".golden")
+		if err != nil {
+			t.Errorf("reading golden: %s", err)
+			continue
+		}
+		if formatted, err := format.Source(want); err != nil || !bytes.Equal(formatted, want) {
+			t.Errorf("%s.golden is not gofmt'd (err=%v)", filename, err)
+		}
+
+		sort.SliceStable(edits, func(i, j int) bool {
+			if edits[i].start != edits[j].start {
+				return edits[i].start < edits[j].start
+			}
+			return edits[i].end < edits[j].end // insertions go before a replacement at the same offset
+		})
+		var got []byte
+		last := edit{}
+		for _, e := range edits {
+			if e == last {
+				continue // the same edit can come from multiple packages (e.g. tests)
+			}
+			if e.start < last.end {
+				t.Errorf("%s: overlapping edits %+v and %+v", filename, last, e)
+				break
+			}
+			got = append(got, src[last.end:e.start]...)
+			got = append(got, e.text...)
+			last = e
+		}
+		got = append(got, src[last.end:]...)
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: fixes do not match golden file\n--- got:\n%s\n--- want:\n%s", filepath.Base(filename), got, want)
+		}
+	}
+}
+
+// TestGoldenTypeChecks ensures that the golden files (along with the testdata files
+// that have no fixes) make up packages that type-check, so that applying the fixes
+// produces a program that still compiles.
+func TestGoldenTypeChecks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping type-checking golden files in short mode")
+	}
+
+	// Packages whose fixes are known to leave type errors for the author to resolve.
+	knownBroken := map[string]string{
+		"generated":      "calls in generated files are not edited",
+		"bodylessmethod": "the compiler doesn't support methods without a body",
+	}
+
+	gopath := copyGoldens(t)
+	cfg := &packages.Config{
+		Mode:  packages.LoadSyntax,
+		Dir:   filepath.Join(gopath, "src"),
+		Env:   append(os.Environ(), "GOPATH="+gopath, "GO111MODULE=off", "GOFLAGS="),
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		t.Fatalf("loading golden packages: %s", err)
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if _, ok := knownBroken[pkg.PkgPath]; ok {
+			return
+		}
+		for _, err := range pkg.Errors {
+			t.Errorf("%s: %s", pkg.PkgPath, err)
+		}
+	})
+}
+
+// copyGoldens copies the testdata packages (using the golden files, if they have them)
+// into a new GOPATH, returning its root.
+func copyGoldens(t *testing.T) (gopath string) {
+	src := filepath.Join(analysistest.TestData(), "src")
+	gopath = t.TempDir()
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if ext := filepath.Ext(path); ext != ".go" && ext != ".s" {
+			return nil // assembly files are kept so that functions without bodies type-check
+		}
+		contents, err := os.ReadFile(path + ".golden")
+		if os.IsNotExist(err) {
+			contents, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(gopath, "src", rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(dst, contents, 0644)
+	})
+	if err != nil {
+		t.Fatalf("copying golden files: %s", err)
+	}
+	return gopath
+}
+
+// TestFixesAreStable ensures that analyzing the golden files (the testdata with all
+// of the fixes applied) suggests no further fixes, so that fixes don't oscillate
+// or need several runs to complete.
+func TestFixesAreStable(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping analyzing golden files in short mode")
+	}
+
+	gopath := copyGoldens(t)
+	cfg := &packages.Config{
+		Mode:  packages.LoadAllSyntax,
+		Dir:   filepath.Join(gopath, "src"),
+		Env:   append(os.Environ(), "GOPATH="+gopath, "GO111MODULE=off", "GOFLAGS="),
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		t.Fatalf("loading golden packages: %s", err)
+	}
+	var roots []*packages.Package
+	for _, pkg := range pkgs {
+		if !strings.HasSuffix(pkg.PkgPath, ".test") {
+			roots = append(roots, pkg)
+		}
+	}
+
+	results, err := Analyze(roots)
+	if err != nil {
+		t.Fatalf("Analyze: %s", err)
+	}
+	for _, result := range results {
+		if len(result.Fixes) > 0 {
+			t.Errorf("%s:%d: %s (after applying the fixes)", filepath.Base(result.Pos.Filename), result.Pos.Line, result.Message)
+		}
+	}
+}
+
+// TestAnalyze ensures that Analyze returns the results for the root packages, using
+// the facts of their dependencies.
+func TestAnalyze(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping loading packages in short mode")
+	}
+
+	testdata := analysistest.TestData()
+	cfg := &packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Dir:  filepath.Join(testdata, "src"),
+		Env:  append(os.Environ(), "GOPATH="+testdata, "GO111MODULE=off", "GOFLAGS="),
+	}
+	pkgs, err := packages.Load(cfg, "chain/a")
+	if err != nil {
+		t.Fatalf("loading packages: %s", err)
+	}
+	if n := packages.PrintErrors(pkgs); n > 0 {
+		t.Fatalf("%d errors loading packages", n)
+	}
+
+	results, err := Analyze(pkgs)
+	if err != nil {
+		t.Fatalf("Analyze: %s", err)
+	}
+
+	// Only the results for chain/a are returned, but they depend on the facts from its dependencies.
+	var got []string
+	for _, result := range results {
+		if result.Package.PkgPath != "chain/a" {
+			t.Errorf("%s: result for %s, want only chain/a", result.Pos, result.Package.PkgPath)
+		}
+		var edits []string
+		for _, fix := range result.Fixes {
+			for _, edit := range fix.Edits {
+				edits = append(edits, fmt.Sprintf("%d-%d:%q", edit.Offset, edit.End, edit.NewText))
+			}
+		}
+		got = append(got, fmt.Sprintf("%s:%d: %s %v", filepath.Base(result.Pos.Filename), result.Pos.Line, result.Message, edits))
+	}
+	want := []string{
+		`a.go:20: Continue plumbing context [670-670:"ctx, " 630-630:"ctx context.Context, " 600-600:"import \"context\"\n" 742-742:"ctx, " 730-730:"ctx context.Context"]`,
+		`a.go:24: Continue plumbing context [760-760:"ctx, "]`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Analyze results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// TestNoDuplicateEdits ensures that the fixes for all diagnostics in a package
+// can be applied together, as tools like gopls will reject duplicate or
+// overlapping edits.
+func TestNoDuplicateEdits(t *testing.T) {
+	testdata := analysistest.TestData()
+	for _, result := range analysistest.Run(t, testdata, Analyzer, "./src/...") {
+		fset := result.Pass.Fset
+		type span struct {
+			filename   string
+			start, end int
+		}
+		var spans []span
+		for _, diag := range result.Diagnostics {
+			for _, fix := range diag.SuggestedFixes {
+				for _, te := range fix.TextEdits {
+					start, end := fset.Position(te.Pos), fset.Position(te.Pos)
+					if te.End.IsValid() {
+						end = fset.Position(te.End)
+					}
+					e := span{start.Filename, start.Offset, end.Offset}
+					for _, prev := range spans {
+						if prev.filename != e.filename {
+							continue
+						}
+						if prev == e || (e.start < prev.end && prev.start < e.end) {
+							t.Errorf("%s: edit %d-%d duplicates or overlaps %d-%d", filepath.Base(e.filename), e.start, e.end, prev.start, prev.end)
+						}
+					}
+					spans = append(spans, e)
+				}
+			}
+		}
+	}
+}
+
+// TestOneParamPerFunction ensures that a function reached from several
+// context.TODO() calls (in it and in its callees) gains a single ctx parameter.
+func TestOneParamPerFunction(t *testing.T) {
+	testdata := analysistest.TestData()
+	params := map[string]int{}
+	for _, result := range analysistest.Run(t, testdata, Analyzer, "twice") {
+		for _, diag := range result.Diagnostics {
+			for _, fix := range diag.SuggestedFixes {
+				for _, te := range fix.TextEdits {
+					if strings.HasPrefix(string(te.NewText), "ctx context.Context") {
+						params[result.Pass.Fset.Position(te.Pos).String()]++
+					}
+				}
+			}
+		}
+	}
+	if got, want := len(params), 3; got != want {
+		t.Errorf("got ctx parameters added at %d positions, want %d: %v", got, want, params)
+	}
+	for pos, count := range params {
+		if count != 1 {
+			t.Errorf("%s: ctx parameter added %d times, want once", pos, count)
+		}
+	}
+}
+
+// TestImportPerFile ensures that each file with a function gaining a ctx parameter
+// gets its own import of context, even when //line directives make its positions
+// refer to another file.
+func TestImportPerFile(t *testing.T) {
+	defer func(orig io.Writer) { LogOutput = orig }(LogOutput)
+	logs := new(bytes.Buffer)
+	LogOutput = logs
+
+	testdata := analysistest.TestData()
+	imports := map[string]int{}
+	for _, result := range analysistest.Run(t, testdata, Analyzer, "imports") {
+		seen := map[token.Pos]bool{} // the same edit is suggested by each diagnostic
+		for _, diag := range result.Diagnostics {
+			for _, fix := range diag.SuggestedFixes {
+				for _, te := range fix.TextEdits {
+					if strings.Contains(string(te.NewText), `"context"`) && !seen[te.Pos] {
+						seen[te.Pos] = true
+						imports[filepath.Base(result.Pass.Fset.PositionFor(te.Pos, false).Filename)]++
+					}
+				}
+			}
+		}
+	}
+	want := map[string]int{"first.go": 1, "second.go": 1, "parse.go": 1}
+	if !reflect.DeepEqual(imports, want) {
+		t.Errorf("context imports added = %v, want %v", imports, want)
+	}
+	if got := logs.String(); got != "" {
+		t.Errorf("unexpected logs:\n%s", got)
+	}
+}
+
+// TestMinimalSpans ensures that a context.TODO() nested in a comparison or other
+// expression is replaced on its own, without touching the expression around it.
+func TestMinimalSpans(t *testing.T) {
+	testdata := analysistest.TestData()
+	for _, result := range analysistest.Run(t, testdata, Analyzer, "exprs") {
+		fset := result.Pass.Fset
+		for _, diag := range result.Diagnostics {
+			var replaced bool
+			for _, fix := range diag.SuggestedFixes {
+				for _, te := range fix.TextEdits {
+					if string(te.NewText) != "ctx" {
+						continue
+					}
+					if te.Pos == diag.Pos && te.End == diag.End {
+						replaced = true
+					} else if te.Pos < diag.End && diag.Pos < te.End {
+						t.Errorf("%s: edit %s-%s overlaps the context.TODO() call", fset.Position(diag.Pos), fset.Position(te.Pos), fset.Position(te.End))
+					}
+				}
+			}
+			if !replaced {
+				t.Errorf("%s: context.TODO() call was not replaced on its own", fset.Position(diag.Pos))
+			}
+		}
+	}
+}
+
+// TestCategories ensures that each kind of diagnostic is reported in its own category.
+func TestCategories(t *testing.T) {
+	testdata := analysistest.TestData()
+	got := map[string]string{}
+	for _, result := range analysistest.Run(t, testdata, Analyzer, "chain/...", "preexisting", "pkglevel", "sadface") {
+		for _, diag := range result.Diagnostics {
+			pos := result.Pass.Fset.Position(diag.Pos)
+			got[fmt.Sprintf("%s:%d", filepath.Base(pos.Filename), pos.Line)] = diag.Category
+		}
+	}
+
+	tests := []struct {
+		pos      string
+		category string
+	}{
+		{"c.go:20", CategoryPlumb},                // gains a ctx parameter
+		{"a.go:20", CategoryTransitive},           // calls a function in another package that gained one
+		{"preexisting.go:37", CategorySubstitute}, // uses the ctx parameter
+		{"pkglevel.go:29", CategorySubstitute},    // uses the root context at package level
+		{"preexisting.go:47", CategorySubstitute}, // names a parameter to use it
+		{"sadface.go:21", CategoryManual},         // a non-context ctx parameter to rename
+		{"sadface.go:32", CategoryManual},         // blocked by a non-context ctx variable
+	}
+	for _, test := range tests {
+		if got, want := got[test.pos], test.category; got != want {
+			t.Errorf("%s: category = %q, want %q", test.pos, got, want)
+		}
+	}
+}
+
+// TestFlags runs the analyzer on each package in testdata/flags with a flag set.
+func TestFlags(t *testing.T) {
+	tests := []struct {
+		flag, value string
+		pkg         string // in testdata/flags
+	}{
+		{"maxdepth", "2", "maxdepth"},
+		{"max-files", "2", "maxfiles"},
+		// The functions reached by a plumbing that is dropped for editing too many files
+		// still gain a context when another plumbing reaches them.
+		{"max-files", "1", "maxfilesrollback"},
+		{"compat-shim", "true", "compatshim"},
+		// No NeedsContext facts are exported (which analysistest would report as
+		// unexpected), and callers in other packages are untouched.
+		{"no-cross-package", "true", "nocross/..."},
+		{"exclude-files", "*.pb.go", "exclude"},
+		{"package-allowlist", "allowlist/service/...", "allowlist/..."},
+		{"fixmode", FixBackground, "fixmode"},
+		{"ctx-position", PositionLast, "ctxlast"},
+		{"ctxname", "c", "ctxname"},
+		{"context-method-names", "Ctx", "ctxmethods"},
+		{"context-import-path", "ctximport/internal/xcontext", "ctximport/..."},
+		{"fix-comments", "true", "fixcomments"},
+		{"entrypoints", "Job,Handle.*,server.ServeStatus", "entrypoints"},
+		{"root-context", "rootCtx", "rootctx"},
+		{"param-names", "*http.Request=req", "paramnames"},
+		{"provider-funcs", "run=0,Pool.Do=1", "providers"},
+		{"deep-provider-search", "true", "deep"},
+		// The functions and files edited by a chain of callers gaining ctx parameters
+		// are counted in the message.
+		{"annotate-reach", "true", "reach"},
+		{"param-comment", "TODO: plumbed", "paramcomment"},
+		{"include-background", "true", "background"},
+	}
+
+	testdata := filepath.Join(analysistest.TestData(), "flags")
+	for _, test := range tests {
+		t.Run(test.pkg, func(t *testing.T) {
+			setFlag(t, test.flag, test.value)
+			analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, test.pkg)
+		})
+	}
+}
+
+// setFlag sets the analyzer's flag name to value (replacing lists rather than adding to
+// them) until the end of the test.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	f := Analyzer.Flags.Lookup(name)
+	if f == nil {
+		t.Fatalf("no flag %q", name)
+	}
+	set := func(value string) error {
+		if r, ok := f.Value.(interface{ Reset() }); ok {
+			r.Reset()
+		}
+		return f.Value.Set(value)
+	}
+	orig := f.Value.String()
+	t.Cleanup(func() {
+		if err := set(orig); err != nil {
+			t.Errorf("restoring -%s=%q: %s", name, orig, err)
+		}
+	})
+	if err := set(value); err != nil {
+		t.Fatalf("setting -%s=%q: %s", name, value, err)
+	}
+}
+
+func TestFlagErrors(t *testing.T) {
+	tests := []struct {
+		flag, value string
+	}{
+		{"root-context", "rootCtx("},
+		{"param-comment", "plumbed */ here"},
+	}
+
+	for _, test := range tests {
+		if err := Analyzer.Flags.Set(test.flag, test.value); err == nil {
+			t.Errorf("setting -%s=%q succeeded, want an error", test.flag, test.value)
+		}
+	}
+}
+
+func TestGitBase(t *testing.T) {
+	defer func(orig string) { GitBase = orig }(GitBase)
+	GitBase = "origin/main"
+
+	defer func(orig func(string, ...string) ([]byte, error)) { gitCommand = orig }(gitCommand)
+	var commands []string
+	gitCommand = func(dir string, args ...string) ([]byte, error) {
+		commands = append(commands, strings.Join(args, " "))
+		switch args[0] {
+		case "diff":
+			return []byte("changed.go\x00"), nil
+		case "ls-files":
+			return []byte("added.go\x00"), nil
+		}
+		return nil, fmt.Errorf("unexpected git command %q", args)
+	}
+
+	testdata := filepath.Join(analysistest.TestData(), "flags")
+	analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, "gitbase")
+
+	if len(commands) == 0 || !strings.Contains(commands[0], " origin/main ") {
+		t.Errorf("git commands %q don't diff against origin/main", commands)
+	}
+}
+
+// TestReportOnlyUnfixable ensures that only the diagnostics without fixes are
+// reported (which analysistest checks against the want comments).
+func TestReportOnlyUnfixable(t *testing.T) {
+	defer func(orig bool) { ReportOnlyUnfixable = orig }(ReportOnlyUnfixable)
+	ReportOnlyUnfixable = true
+
+	testdata := filepath.Join(analysistest.TestData(), "flags")
+	for _, result := range analysistest.Run(t, testdata, Analyzer, "unfixable") {
+		for _, diag := range result.Diagnostics {
+			if hasEdits(diag) {
+				t.Errorf("unexpected suggested fixes for %q", diag.Message)
+			}
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string // in testdata
+		pkg     string
+		exclude string // for --exclude-files
+		want    string
+	}{
+		{
+			name: "plan",
+			dir:  "flags",
+			pkg:  "dryrun",
+			want: `dryrun/fetch.go: 2 functions gain a ctx parameter, 2 call sites rewritten, context import added
+	17:1: import context
+	17:6: add ctx parameter to fetch
+	18:5: pass ctx to get
+	23:6: add ctx parameter to get
+	24:6: pass ctx to wait
+dryrun/wait.go: 1 function gains a ctx parameter, 0 call sites rewritten
+	21:6: add ctx parameter to wait
+	22:4: replace context.TODO() with ctx
+`,
+		},
+		{
+			// The edits that the fixes leave out aren't planned either.
+			name:    "excluded",
+			dir:     "flags",
+			pkg:     "exclude",
+			exclude: "*.pb.go",
+			want: `exclude/exclude.go: 1 function gains a ctx parameter, 0 call sites rewritten
+	21:6: add ctx parameter to fetch
+	22:8: replace context.TODO() with ctx
+`,
+		},
+		{
+			name: "generated",
+			pkg:  "generated",
+			want: `generated/generated.go: 1 function gains a ctx parameter, 0 call sites rewritten
+	21:6: add ctx parameter to fetch
+	22:8: replace context.TODO() with ctx
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func(orig io.Writer) { Output = orig }(Output)
+			out := new(bytes.Buffer)
+			Output = out
+			setFlag(t, "dry-run", "true")
+			if test.exclude != "" {
+				setFlag(t, "exclude-files", test.exclude)
+			}
+
+			testdata := filepath.Join(analysistest.TestData(), test.dir)
+			for _, result := range analysistest.Run(t, testdata, Analyzer, test.pkg) {
+				for _, diag := range result.Diagnostics {
+					if len(diag.SuggestedFixes) > 0 {
+						t.Errorf("unexpected suggested fixes for %q", diag.Message)
+					}
+				}
+			}
+
+			got := strings.ReplaceAll(out.String(), filepath.Join(testdata, "src")+string(filepath.Separator), "")
+			if got != test.want {
+				t.Errorf("plan:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestOnlyTODOs(t *testing.T) {
+	tests := []struct {
+		dir, pkg string
+		want     []string
+	}{
+		{
+			dir: analysistest.TestData(),
+			pkg: "preexisting",
+			want: []string{
+				"23: context.TODO() in a can be plumbed from b (ctx): b -> a",
+				"37: context.TODO() in d can use ctx",
+				"75: context.TODO() in i can use r.Context()",
+				"80: context.TODO() in j can use r.Context()",
+			},
+		},
+		{
+			dir: analysistest.TestData(),
+			pkg: "demo",
+			want: []string{
+				"42: context.TODO() in fetch can be plumbed from main (context.Background()): main -> fetch",
+			},
+		},
+		{
+			dir: filepath.Join(analysistest.TestData(), "flags"),
+			pkg: "maxdepth",
+			want: []string{
+				"22: context.TODO() in leaf is not reachable from a function with a context",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pkg, func(t *testing.T) {
+			// Load the package (checking its usual diagnostics) before auditing it.
+			OnlyTODOs = false
+			var pass *analysis.Pass
+			for _, result := range analysistest.Run(t, test.dir, Analyzer, test.pkg) {
+				pass = result.Pass
+			}
+
+			defer func(orig bool) { OnlyTODOs = orig }(OnlyTODOs)
+			OnlyTODOs = true
+
+			var got []string
+			pass.Report = func(diag analysis.Diagnostic) {
+				if len(diag.SuggestedFixes) > 0 {
+					t.Errorf("unexpected suggested fixes for %q", diag.Message)
+				}
+				if got, want := diag.Category, CategoryAudit; got != want {
+					t.Errorf("category for %q = %q, want %q", diag.Message, got, want)
+				}
+				got = append(got, fmt.Sprintf("%d: %s", pass.Fset.Position(diag.Pos).Line, diag.Message))
+			}
+			if _, err := Analyzer.Run(pass); err != nil {
+				t.Fatalf("Run: %s", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("audit:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestJSONDiagnostics(t *testing.T) {
+	defer func(orig bool) { JSONDiagnostics = orig }(JSONDiagnostics)
+	defer func(orig io.Writer) { Output = orig }(Output)
+	out := new(bytes.Buffer)
+	JSONDiagnostics, Output = true, out
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, Analyzer, "basic")
+	var reported int
+	for _, result := range results {
+		reported += len(result.Diagnostics)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if got, want := len(lines), reported; got != want {
+		t.Fatalf("got %d lines of JSON, want one for each of the %d diagnostics:\n%s", got, want, out)
+	}
+	for _, line := range lines {
+		// Every field must be present...
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatalf("decoding %q: %s", line, err)
+		}
+		for _, key := range []string{"file", "line", "column", "offset", "end", "category", "message", "fixes"} {
+			if _, ok := fields[key]; !ok {
+				t.Errorf("%s: missing %q", line, key)
+			}
+		}
+
+		// ... and nothing else.
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.DisallowUnknownFields()
+		var diag jsonDiagnostic
+		if err := dec.Decode(&diag); err != nil {
+			t.Fatalf("decoding %q: %s", line, err)
+		}
+
+		src, err := os.ReadFile(diag.File)
+		if err != nil {
+			t.Fatalf("reading source: %s", err)
+		}
+		if got, want := string(src[diag.Offset:diag.End]), "context.TODO()"; got != want {
+			t.Errorf("%s:%d:%d: diagnostic offsets cover %q, want %q", filepath.Base(diag.File), diag.Line, diag.Column, got, want)
+		}
+		for _, fix := range diag.Fixes {
+			if len(fix.Edits) == 0 {
+				t.Errorf("%s:%d:%d: fix %q has no edits", filepath.Base(diag.File), diag.Line, diag.Column, fix.Message)
+			}
+			for _, edit := range fix.Edits {
+				if edit.Offset > edit.End || edit.End > len(src) {
+					t.Errorf("%s:%d:%d: invalid edit %+v", filepath.Base(diag.File), diag.Line, diag.Column, edit)
+				}
+			}
+		}
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	defer func(orig bool) { Verbose = orig }(Verbose)
+	defer func(orig io.Writer) { LogOutput = orig }(LogOutput)
+
+	tests := []struct {
+		verbose bool
+		want    string
+	}{
+		{verbose: false, want: ""},
+		{verbose: true, want: "Adding context to"},
+	}
+	for _, test := range tests {
+		logs := new(bytes.Buffer)
+		Verbose, LogOutput = test.verbose, logs
+
+		analysistest.Run(t, analysistest.TestData(), Analyzer, "basic")
+		if got := logs.String(); test.want == "" && got != "" {
+			t.Errorf("verbose=%v: unexpected logs:\n%s", test.verbose, got)
+		} else if !strings.Contains(got, test.want) {
+			t.Errorf("verbose=%v: logs missing %q:\n%s", test.verbose, test.want, got)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		pkg     string // in testdata/flags
+		exclude string // for --exclude-files
+		want    string
+	}{
+		{
+			// generated() in generated.go isn't counted, since it isn't edited.
+			pkg:  "summary",
+			want: "summary: 1 context.TODO() call, 3 functions gained a ctx parameter, 1 file imported context, 1 unfixable diagnostic\n",
+		},
+		{
+			// The functions in excluded files aren't counted, since they aren't edited.
+			pkg:     "exclude",
+			exclude: "*.pb.go",
+			want:    "exclude: 1 context.TODO() call, 1 function gained a ctx parameter, 0 files imported context, 1 unfixable diagnostic\n",
+		},
+	}
+
+	testdata := filepath.Join(analysistest.TestData(), "flags")
+	for _, test := range tests {
+		t.Run(test.pkg, func(t *testing.T) {
+			defer func(orig io.Writer) { Output = orig }(Output)
+			out := new(bytes.Buffer)
+			Output = out
+			setFlag(t, "summary", "true")
+			if test.exclude != "" {
+				setFlag(t, "exclude-files", test.exclude)
+			}
+
+			analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, test.pkg)
+			if got := out.String(); got != test.want {
+				t.Errorf("summary:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	defer func(orig string) { CacheDir = orig }(CacheDir)
+	defer func(orig bool) { Verbose = orig }(Verbose)
+	defer func(orig io.Writer) { LogOutput = orig }(LogOutput)
+	logs := new(bytes.Buffer)
+	CacheDir, Verbose, LogOutput = t.TempDir(), true, logs
+
+	testdata := t.TempDir()
+	filename := filepath.Join(testdata, "src", "cached", "cached.go")
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(body string) {
+		src := "package cached\n\nimport \"context\"\n\nfunc fetch() {\n" + body + "}\n\nfunc check(ctx context.Context) {}\n"
+		if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const skipped = "Skipping cached: "
+
+	write("")
+	analysistest.Run(t, testdata, Analyzer, "cached")
+	if strings.Contains(logs.String(), skipped) {
+		t.Errorf("cold run skipped the package:\n%s", logs)
+	}
+
+	logs.Reset()
+	analysistest.Run(t, testdata, Analyzer, "cached")
+	if !strings.Contains(logs.String(), skipped) {
+		t.Errorf("warm run did not skip the unchanged package:\n%s", logs)
+	}
+
+	logs.Reset()
+	write("\tcheck(context.TODO()) // want \"Plumb context\"\n")
+	analysistest.Run(t, testdata, Analyzer, "cached")
+	if strings.Contains(logs.String(), skipped) {
+		t.Errorf("run after a change skipped the package:\n%s", logs)
+	}
+}
+
+// BenchmarkCache measures analyzing a large package that needs no changes,
+// without the cost of loading it, with and without a warm cache.
+func BenchmarkCache(b *testing.B) {
+	defer func(orig string) { CacheDir = orig }(CacheDir)
+	CacheDir = ""
+
+	var pass *analysis.Pass
+	for _, result := range analysistest.Run(b, analysistest.TestData(), Analyzer, "encoding/json") {
+		if pass == nil || len(result.Pass.Files) > len(pass.Files) {
+			pass = result.Pass // the package with its tests
+		}
+	}
+	report := pass.Report
+	analyze := func(b *testing.B) {
+		pass.Report = report // run wraps it each time
+		if _, err := Analyzer.Run(pass); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("cold", func(b *testing.B) {
+		CacheDir = ""
+		for i := 0; i < b.N; i++ {
+			analyze(b)
+		}
+	})
+	b.Run("warm", func(b *testing.B) {
+		CacheDir = b.TempDir()
+		analyze(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			analyze(b)
+		}
+	})
+}
+
+// writeLargePackage writes a package named "large" to the GOPATH-style dir, with a
+// single context.TODO() called (directly and transitively) from funcs functions
+// that each have many local variables to search for a context.
+func writeLargePackage(tb testing.TB, dir string, funcs int) {
+	var src strings.Builder
+	src.WriteString("package large\n\n")
+	src.WriteString("import (\n\t\"bytes\"\n\t\"context\"\n\t\"strings\"\n)\n\n")
+	src.WriteString("func leaf() {\n\t_ = context.TODO() // want \"Plumb context\"\n}\n")
+	for i := 0; i < funcs; i++ {
+		fmt.Fprintf(&src, "\nfunc f%d(n int) {\n", i)
+		for j := 0; j < 10; j++ {
+			fmt.Fprintf(&src, "\tvar buf%d bytes.Buffer\n\tvar sb%d strings.Builder\n\terr%d := error(nil)\n", j, j, j)
+			fmt.Fprintf(&src, "\t_, _, _ = buf%d, sb%d, err%d\n", j, j, j)
+		}
+		src.WriteString("\tif n > 0 {\n\t\tleaf()\n\t}\n")
+		src.WriteString("\tfor i := 0; i < n; i++ {\n\t\tleaf()\n\t}\n")
+		src.WriteString("\tleaf()\n")
+		if i+1 < funcs {
+			fmt.Fprintf(&src, "\tf%d(n - 1)\n", i+1)
+		}
+		src.WriteString("}\n")
+	}
+
+	pkgDir := filepath.Join(dir, "src", "large")
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		tb.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, "large.go"), []byte(src.String()), 0644); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func BenchmarkLargePackage(b *testing.B) {
+	dir := b.TempDir()
+	writeLargePackage(b, dir, 1000)
+	results := analysistest.Run(b, dir, Analyzer, "large")
+	pass := results[0].Pass
+	report := pass.Report
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pass.Report = report // run wraps it each time
+		if _, err := Analyzer.Run(pass); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// gitCommand runs git with the given arguments in dir, returning its standard output.
+// It is a variable so that tests can fake the repository.
+var gitCommand = func(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+		return nil, fmt.Errorf("git %s: %s", args[0], bytes.TrimSpace(exit.Stderr))
+	}
+	return out, err
+}
+
+// changedFiles returns the files of the package that have changed since GitBase
+// (including uncommitted changes and untracked files), or nil if it is not set.
+func changedFiles(p *analysis.Pass) (map[string]bool, error) {
+	if GitBase == "" || len(p.Files) == 0 {
+		return nil, nil
+	}
+	// All of the files of a package are in the same directory, and paths are listed relative to it.
+	dir := filepath.Dir(filename(p.Fset, p.Files[0].Pos()))
+	diff, err := gitCommand(dir, "diff", "--name-only", "-z", "--relative", GitBase, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitCommand(dir, "ls-files", "-z", "--others", "--exclude-standard", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	changed := map[string]bool{}
+	for _, out := range [][]byte{diff, untracked} {
+		for _, name := range bytes.Split(out, []byte{0}) {
+			if len(name) > 0 {
+				changed[filepath.Join(dir, filepath.FromSlash(string(name)))] = true
+			}
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"encoding/json"
+	"go/token"
+	"io"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A jsonDiagnostic is the JSON form of a diagnostic written when JSONDiagnostics is set.
+type jsonDiagnostic struct {
+	File     string    `json:"file"`
+	Line     int       `json:"line"`
+	Column   int       `json:"column"`
+	Offset   int       `json:"offset"`
+	End      int       `json:"end"`
+	Category string    `json:"category,omitempty"`
+	Message  string    `json:"message"`
+	Fixes    []jsonFix `json:"fixes,omitempty"`
+}
+
+// A jsonFix is the JSON form of a suggested fix.
+type jsonFix struct {
+	Message string     `json:"message"`
+	Edits   []jsonEdit `json:"edits"`
+}
+
+// A jsonEdit is the JSON form of a text edit, which replaces the bytes of File
+// from Offset up to (but not including) End with NewText.
+type jsonEdit struct {
+	File    string `json:"file"`
+	Offset  int    `json:"offset"`
+	End     int    `json:"end"`
+	NewText string `json:"new_text"`
+}
+
+// writeJSON writes each diagnostic reported by p to Output as a line of JSON,
+// in addition to reporting it normally.
+func writeJSON(p *analysis.Pass, logger *logger) {
+	actualReport := p.Report
+	p.Report = func(diag analysis.Diagnostic) {
+		actualReport(diag)
+
+		start := p.Fset.Position(diag.Pos)
+		out := jsonDiagnostic{
+			File:     start.Filename,
+			Line:     start.Line,
+			Column:   start.Column,
+			Offset:   start.Offset,
+			End:      offset(p.Fset, diag.End, start),
+			Category: diag.Category,
+			Message:  diag.Message,
+		}
+		for _, fix := range diag.SuggestedFixes {
+			jfix := jsonFix{Message: fix.Message}
+			for _, te := range fix.TextEdits {
+				pos := p.Fset.PositionFor(te.Pos, false) // edits are to the file itself, not its //line source
+				jfix.Edits = append(jfix.Edits, jsonEdit{
+					File:    pos.Filename,
+					Offset:  pos.Offset,
+					End:     offset(p.Fset, te.End, pos),
+					NewText: string(te.NewText),
+				})
+			}
+			out.Fixes = append(out.Fixes, jfix)
+		}
+
+		line, err := json.Marshal(out)
+		if err != nil {
+			logger.Warnf("failed to encode diagnostic: %s", err)
+			return
+		}
+		outMu.Lock()
+		defer outMu.Unlock()
+		if _, err := Output.Write(append(line, '\n')); err != nil {
+			logger.Warnf("failed to write diagnostic: %s", err)
+		}
+	}
+}
+
+// WriteJSON writes each of results to w as a line of JSON, in the same form as the
+// diagnostics written for JSONDiagnostics.
+func WriteJSON(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	for _, res := range results {
+		out := jsonDiagnostic{
+			File:     res.Pos.Filename,
+			Line:     res.Pos.Line,
+			Column:   res.Pos.Column,
+			Offset:   res.Pos.Offset,
+			End:      res.End.Offset,
+			Category: res.Category,
+			Message:  res.Message,
+		}
+		for _, fix := range res.Fixes {
+			jfix := jsonFix{Message: fix.Message}
+			for _, edit := range fix.Edits {
+				jfix.Edits = append(jfix.Edits, jsonEdit{
+					File:    edit.Filename,
+					Offset:  edit.Offset,
+					End:     edit.End,
+					NewText: edit.NewText,
+				})
+			}
+			out.Fixes = append(out.Fixes, jfix)
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// offset returns the file offset of end, which defaults to start if it is not valid.
+func offset(fset *token.FileSet, end token.Pos, start token.Position) int {
+	if !end.IsValid() {
+		return start.Offset
+	}
+	return fset.Position(end).Offset
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// A logger writes progress and warning messages to LogOutput.
+//
+// Progress messages (like which functions are gaining a context parameter)
+// are only written when Verbose is set; warnings that indicate a broken fix
+// are always written.
+type logger struct {
+	w       io.Writer
+	verbose bool
+}
+
+var logMu sync.Mutex
+
+func newLogger() *logger {
+	return &logger{
+		w:       LogOutput,
+		verbose: Verbose,
+	}
+}
+
+// Infof logs a progress message if verbose logging is enabled.
+func (l *logger) Infof(format string, args ...interface{}) {
+	if !l.verbose {
+		return
+	}
+	l.printf(format, args...)
+}
+
+// Warnf logs a warning, regardless of verbosity.
+func (l *logger) Warnf(format string, args ...interface{}) {
+	l.printf("Warning: "+format, args...)
+}
+
+func (l *logger) printf(format string, args ...interface{}) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	fmt.Fprintf(l.w, format+"\n", args...)
+}
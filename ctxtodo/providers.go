@@ -0,0 +1,104 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A providerFunc is a parsed entry of ProviderFuncs.
+type providerFunc struct {
+	name  *regexp.Regexp
+	index int // of the parameter of the function literal that is the context
+}
+
+func parseProviderFunc(entry string) (providerFunc, error) {
+	eq := strings.LastIndex(entry, "=")
+	if eq < 0 {
+		return providerFunc{}, fmt.Errorf("must be regexp=index")
+	}
+	name, err := regexp.Compile("^(?:" + entry[:eq] + ")$")
+	if err != nil {
+		return providerFunc{}, err
+	}
+	index, err := strconv.Atoi(entry[eq+1:])
+	if err != nil || index < 0 {
+		return providerFunc{}, fmt.Errorf("index must be a non-negative integer")
+	}
+	return providerFunc{name, index}, nil
+}
+
+// providedContext returns the expression for the context that lit (the last node of
+// path) is called with, if it is passed to one of ProviderFuncs.
+//
+// The context parameter has to be named to be used; if it isn't declared as a
+// context.Context, it is asserted to be one.
+func (r *runner) providedContext(path astPath, lit *ast.FuncLit, direct bool) (string, bool) {
+	if !direct || len(r.providerFuncs) == 0 || len(path) == 0 {
+		return "", false
+	}
+	_, parent := path.pop()
+	call, ok := parent.(*ast.CallExpr)
+	if !ok || isCallOf(call, lit) {
+		return "", false
+	}
+	fun, ok := r.TypesInfo.Uses[r.calleeIdent(call)].(*types.Func)
+	if !ok {
+		return "", false
+	}
+
+	name := funcName(fun)
+	for _, pf := range r.providerFuncs {
+		if !pf.name.MatchString(name) {
+			continue
+		}
+		param := paramAt(lit.Type.Params, pf.index)
+		if param == nil || param.Name == "_" {
+			continue
+		}
+		typ := r.TypesInfo.TypeOf(param)
+		if expr, ok := r.contextExpr(param.Name, typ, true); ok {
+			return expr, true
+		}
+		if types.IsInterface(typ) {
+			return fmt.Sprintf("%s.(%s)", param.Name, r.contextRef(lit.Pos(), "Context")), true
+		}
+	}
+	return "", false
+}
+
+// paramAt returns the name of the parameter at index in params, or nil if it is unnamed
+// (or there aren't that many).
+func paramAt(params *ast.FieldList, index int) *ast.Ident {
+	for _, field := range params.List {
+		if len(field.Names) == 0 {
+			if index == 0 {
+				return nil
+			}
+			index--
+			continue
+		}
+		if index < len(field.Names) {
+			return field.Names[index]
+		}
+		index -= len(field.Names)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// shimName returns the name that funcDecl is renamed to with CompatShim, so that a
+// shim with its original name and signature can call it with the RootContext.
+//
+// Only exported functions (not methods) whose parameters are all named, and whose
+// uses in the package are all calls by name, can be given a shim; others
+// gain a ctx parameter as usual.
+func (r *runner) shimName(funcDecl *ast.FuncDecl) (string, bool) {
+	fun := r.TypesInfo.ObjectOf(funcDecl.Name).(*types.Func)
+	sig := fun.Type().(*types.Signature)
+	if !CompatShim || funcDecl.Recv != nil || !fun.Exported() || sig.TypeParams().Len() > 0 || len(r.values[fun]) > 0 {
+		return "", false
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		if name := sig.Params().At(i).Name(); name == "" || name == "_" {
+			return "", false
+		}
+	}
+	for _, caller := range r.callers[fun] {
+		if ident, ok := caller.call.Fun.(*ast.Ident); !ok || r.TypesInfo.Uses[ident] != fun {
+			return "", false
+		}
+	}
+	name := funcDecl.Name.Name + "WithContext"
+	if r.Pkg.Scope().Lookup(name) != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// editsToAddShim renames funcDecl (which is gaining a ctx parameter) to name, and adds
+// a deprecated shim after it with its original name and signature.
+func (r *runner) editsToAddShim(funcDecl *ast.FuncDecl, name string) (edits []analysis.TextEdit) {
+	orig := funcDecl.Name.Name
+	rename := r.editToRenameIdent(funcDecl.Name, name)
+	r.planned(rename, funcDecl.Name.Pos(), planOther, "rename %s to %s, adding a compatibility shim", orig, name)
+	edits = append(edits, rename)
+
+	// The doc comment describes the renamed function now
+	if doc := funcDecl.Doc; doc != nil {
+		first := doc.List[0]
+		if prefix := "// " + orig + " "; strings.HasPrefix(first.Text, prefix) {
+			at := first.Pos() + token.Pos(len("// "))
+			edits = append(edits, analysis.TextEdit{Pos: at, End: at + token.Pos(len(orig)), NewText: []byte(name)})
+		}
+	}
+
+	var args []string
+	for _, field := range funcDecl.Type.Params.List {
+		for _, ident := range field.Names {
+			args = append(args, ident.Name)
+		}
+	}
+	root, _ := r.rootContext(funcDecl.Pos())
+	sig := r.TypesInfo.ObjectOf(funcDecl.Name).Type().(*types.Signature)
+	switch {
+	case sig.Variadic():
+		args[len(args)-1] += "..."
+		args = append([]string{root}, args...)
+	case CtxPosition == PositionLast:
+		args = append(args, root)
+	default:
+		args = append([]string{root}, args...)
+	}
+	call := fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+	if sig.Results().Len() > 0 {
+		call = "return " + call
+	}
+
+	src := r.source(funcDecl.Pos())
+	start, end := r.Fset.Position(funcDecl.Type.Params.Pos()).Offset, r.Fset.Position(funcDecl.Type.End()).Offset
+	var shim strings.Builder
+	fmt.Fprintf(&shim, "\n\n// %s calls %s with %s.\n", orig, name, root)
+	fmt.Fprintf(&shim, "//\n// Deprecated: Use %s.\n", name)
+	fmt.Fprintf(&shim, "func %s%s {\n\t%s\n}", orig, src[start:end], call)
+	edits = append(edits, analysis.TextEdit{
+		Pos:     funcDecl.End(),
+		End:     funcDecl.End(),
+		NewText: []byte(shim.String()),
+	})
+	return edits
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxtodo
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A summary counts the work done for a package, for tracking a migration across runs.
+type summary struct {
+	todos     int // context.TODO() calls found
+	params    int // functions gaining a ctx parameter
+	imports   int // files gaining a context import
+	unfixable int // diagnostics reported without any edits
+
+	gaining   []string // files of the functions gaining a ctx parameter, if they can be edited
+	importing []string // files that a context import is being added to, if they can be edited
+}
+
+// countUnfixable wraps p.Report to count the diagnostics that are reported
+// without a suggested fix (e.g. because the edits were filtered out).
+func (s *summary) countUnfixable(p *analysis.Pass) {
+	actualReport := p.Report
+	p.Report = func(diag analysis.Diagnostic) {
+		if !hasEdits(diag) {
+			s.unfixable++
+		}
+		actualReport(diag)
+	}
+}
+
+func hasEdits(diag analysis.Diagnostic) bool {
+	for _, fix := range diag.SuggestedFixes {
+		if len(fix.TextEdits) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSummary writes the summary for the package to Output.
+func (r *runner) writeSummary() {
+	s := r.summary
+	for _, todo := range r.todos {
+		if !todo.background {
+			s.todos++
+		}
+	}
+	for _, filename := range s.gaining {
+		if r.skip(filename) == "" && !strings.HasPrefix(filename, ModuleCache) {
+			s.params++
+		}
+	}
+	for _, filename := range s.importing {
+		if r.skip(filename) == "" && !strings.HasPrefix(filename, ModuleCache) {
+			s.imports++
+		}
+	}
+	if s.todos == 0 && s.params == 0 && s.imports == 0 && s.unfixable == 0 {
+		return // nothing to report (e.g. a dependency analyzed for facts)
+	}
+
+	outMu.Lock()
+	defer outMu.Unlock()
+	if _, err := fmt.Fprintf(Output, "%s: %s, %s gained a %s parameter, %s imported context, %s\n",
+		r.Pkg.Path(), plural(s.todos, "context.TODO() call"), plural(s.params, "function"), ContextName,
+		plural(s.imports, "file"), plural(s.unfixable, "unfixable diagnostic")); err != nil {
+		r.logger.Warnf("failed to write summary: %s", err)
+	}
+}
@@ -12,14 +12,14 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package sadface
+package service
 
 import (
 	"context"
 )
 
-func a(ctx bool) { // want "Non-context ctx parameter"
-	// want "Plumb context"
+func Fetch() { // want Fetch:"NeedsContext"
+	check(context.TODO()) // want "Plumb context"
 }
 
-var _ = context.Background() // don't drop the context import
+func check(ctx context.Context) {}
@@ -0,0 +1,45 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+func main() {
+	ctx := context.Background()
+	serve(ctx)
+}
+
+func serve(ctx context.Context) {
+	http.HandleFunc("/", handler)
+	http.HandleFunc("/inline", func(w http.ResponseWriter, r *http.Request) {
+		lookup(context.Background(), r.URL.Path) // want "Plumb context"
+	})
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background() // want "Plumb context"
+	lookup(ctx, r.URL.Path)
+}
+
+func lookup(ctx context.Context, key string) {
+	_ = detached()
+}
+
+func detached() context.Context {
+	return context.Background()
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compatshim
+
+import (
+	"context"
+	"net/http"
+)
+
+// Client talks to the service.
+type Client struct {
+	ctx  context.Context
+	http *http.Client
+}
+
+// An Option configures a Client.
+type Option func(*Client)
+
+// New returns a Client configured by opts.
+func New(opts ...Option) *Client {
+	c := &Client{ctx: context.TODO(), http: http.DefaultClient} // want "Plumb context"
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Dial returns a Client for addr.
+//
+// It calls New, so it gets a shim too.
+func Dial(addr string, timeout int) (*Client, error) {
+	return New(), nil
+}
+
+// Get is a method, so it gains a ctx parameter without a shim.
+func (c *Client) Get(url string) error { // want Get:"NeedsContext"
+	use(context.TODO()) // want "Plumb context"
+	return nil
+}
+
+func use(ctx context.Context) {}
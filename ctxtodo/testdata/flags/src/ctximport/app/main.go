@@ -12,15 +12,17 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// plumber is a tool to help with plumbing Go contexts through multiple layers.
 package main
 
 import (
-	"golang.org/x/tools/go/analysis/singlechecker"
-
-	"github.com/kylelemons/plumber/internal/ctxtodo"
+	"fmt"
 )
 
+func serve() {
+	handle()
+	fmt.Println("served")
+}
+
 func main() {
-	singlechecker.Main(ctxtodo.Analyzer)
+	serve()
 }
@@ -0,0 +1,53 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxlast
+
+import (
+	"context"
+)
+
+func check(ctx context.Context) {}
+
+type Builder struct{}
+
+func (b *Builder) Build(name string, size int) error { // want Build:"NeedsContext"
+	check(context.TODO()) // want "Plumb context"
+	return nil
+}
+
+func (b *Builder) Options(name string, opts ...string) { // want "Adding ctx as the first parameter of variadic Options" Options:"NeedsContext"
+	check(context.TODO()) // want "Plumb context"
+}
+
+func multi(
+	a int,
+	b string,
+) {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func none() {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func caller(b *Builder) {
+	b.Build("x", 1)
+	b.Options("y", "z")
+	multi(
+		1,
+		"two",
+	)
+	none()
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxmethods
+
+import (
+	"context"
+)
+
+// Stream is like a gRPC stream, which exposes its context via Ctx.
+type Stream struct{ ctx context.Context }
+
+func (s *Stream) Ctx() context.Context { return s.ctx }
+
+// Conn has a Ctx method with the wrong shape, so it must not be used.
+type Conn struct{}
+
+func (c *Conn) Ctx(id int) context.Context { return context.Background() }
+
+func handle(s *Stream) {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func dial(c *Conn) {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func check(ctx context.Context) {}
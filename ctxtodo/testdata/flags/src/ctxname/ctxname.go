@@ -0,0 +1,34 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ctxname
+
+import (
+	"context"
+	"net"
+)
+
+func a() {
+	c := context.TODO() // want "Plumb context"
+	_ = c
+}
+
+func b(addr string) (net.Conn, error) {
+	return (&net.Dialer{}).DialContext(context.TODO(), "tcp", addr) // want "Plumb context"
+}
+
+func caller() {
+	a()
+	b("localhost:80")
+}
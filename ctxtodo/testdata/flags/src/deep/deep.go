@@ -0,0 +1,55 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deep
+
+import (
+	"context"
+	"net/http"
+)
+
+type Request struct {
+	Ctx  context.Context
+	Name string
+}
+
+type envelope struct {
+	id      int
+	request *http.Request
+}
+
+type nested struct {
+	env envelope
+}
+
+// handle can use the context in a field of its parameter.
+func handle(req Request) {
+	fetch(req.Name)
+}
+
+// wrapped can use the Context method of a field of its parameter.
+func wrapped(env *envelope) {
+	fetch("wrapped")
+}
+
+// tooDeep would need two levels of fields, so it gains a parameter instead.
+func tooDeep(n nested) {
+	fetch("deep")
+}
+
+func fetch(name string) {
+	use(context.TODO(), name) // want "Plumb context"
+}
+
+func use(ctx context.Context, name string) {}
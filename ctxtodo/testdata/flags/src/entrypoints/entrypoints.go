@@ -0,0 +1,58 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entrypoints
+
+import (
+	"context"
+	"net/http"
+)
+
+// Handler is registered by name, so its signature can't change.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	fetch()
+}
+
+// HandleEvent matches the same pattern as Handler.
+func HandleEvent(name string) {
+	fetch()
+}
+
+// Job is run by a scheduler that looks it up by name.
+func Job() {
+	fetch()
+}
+
+// Jobs doesn't match the whole pattern for Job, so it gains a parameter.
+func Jobs() { // want Jobs:"NeedsContext"
+	fetch()
+}
+
+type server struct{}
+
+// ServeStatus is registered by name too.
+func (s *server) ServeStatus() {
+	fetch()
+}
+
+// serve is not an entry point, so it gains a parameter.
+func (s *server) serve() {
+	fetch()
+}
+
+func fetch() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func use(ctx context.Context) {}
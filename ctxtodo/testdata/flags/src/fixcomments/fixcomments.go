@@ -0,0 +1,51 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fixcomments
+
+import (
+	"context"
+)
+
+func check(ctx context.Context) {}
+
+func stale() {
+	// TODO: plumb context
+	check(context.TODO()) // want "Plumb context"
+}
+
+func multiline() {
+	// TODO(someone): this should get a real context
+	// from the caller once it has one.
+	check(context.TODO()) // want "Plumb context"
+}
+
+func initStmt() error {
+	// TODO: use the caller's context
+	if ctx := context.TODO(); ctx.Err() != nil { // want "Plumb context"
+		return ctx.Err()
+	}
+	return nil
+}
+
+func unrelated() {
+	// Check before doing anything else.
+	check(context.TODO()) // want "Plumb context"
+}
+
+func separated() {
+	// TODO: plumb context
+
+	check(context.TODO()) // want "Plumb context"
+}
@@ -12,14 +12,25 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package sadface
+package maxdepth
 
 import (
 	"context"
 )
 
-func a(ctx bool) { // want "Non-context ctx parameter"
+func leaf() {
 	_ = context.TODO() // want "Plumb context"
 }
 
-var _ = context.Background() // don't drop the context import
+func mid() {
+	leaf()
+}
+
+func both() {
+	mid()
+	leaf()
+}
+
+func top() {
+	both()
+}
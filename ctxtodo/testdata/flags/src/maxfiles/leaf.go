@@ -0,0 +1,31 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maxfiles
+
+import (
+	"context"
+	"net/http"
+)
+
+func leaf() {
+	use(context.TODO()) // want `Not plumbing context: it would edit 3 files \(more than --max-files 2\)`
+}
+
+// handler's context.TODO() only edits this file, so it is still fixed.
+func handler(w http.ResponseWriter, r *http.Request) {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func use(ctx context.Context) {}
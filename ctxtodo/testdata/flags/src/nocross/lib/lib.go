@@ -0,0 +1,36 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import "context"
+
+func Fetch(key string) string { // want "Not adding context to exported Fetch \\(--no-cross-package\\)"
+	check(context.TODO()) // want "Plumb context"
+	return key
+}
+
+func Load(keys ...string) (values []string) { // want "Not adding context to exported Load \\(--no-cross-package\\)"
+	for _, key := range keys {
+		values = append(values, lookup(key))
+	}
+	return values
+}
+
+func lookup(key string) string {
+	check(context.TODO()) // want "Plumb context"
+	return key
+}
+
+func check(ctx context.Context) {}
@@ -0,0 +1,61 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package providers
+
+import "context"
+
+// run calls fn with a context, but declares it as an interface{}.
+func run(fn func(v interface{})) {
+	fn(context.Background())
+}
+
+// A Pool runs jobs with the context of the worker that runs them.
+type Pool struct{}
+
+func (p *Pool) Do(fn func(id int, ctx context.Context)) {
+	fn(0, context.Background())
+}
+
+func job() {
+	run(func(v interface{}) {
+		use(context.TODO()) // want "Plumb context"
+	})
+}
+
+// fetch gains a ctx parameter, which the callback passes along.
+func fetch() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func jobs(p *Pool) {
+	run(func(v interface{}) {
+		fetch()
+	})
+	// The context parameter has to be named to be used
+	p.Do(func(id int, _ context.Context) {
+		fetch()
+	})
+}
+
+// other isn't one of the provider functions, so its callback uses the context of schedule.
+func other(fn func(v interface{})) {}
+
+func schedule() {
+	other(func(v interface{}) {
+		use(context.TODO()) // want "Plumb context"
+	})
+}
+
+func use(ctx context.Context) {}
@@ -0,0 +1,46 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unfixable
+
+import (
+	"context"
+	"time"
+)
+
+// fixable's context.TODO() is fixed by plumbing, so it isn't reported.
+func fixable() {
+	use(context.TODO())
+}
+
+// refresh is plumbed with a context.Background(), which is a fix too.
+func refresh() {
+	use(context.TODO())
+}
+
+func schedule() {
+	time.AfterFunc(time.Minute, refresh) // want "Not adding context to refresh, its signature is fixed by this use"
+}
+
+func collide() {
+	ctx := 42 // want "Not adding context to collide, it declares a non-context ctx"
+	helper()
+	_ = ctx
+}
+
+func helper() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func use(ctx context.Context) {}
@@ -0,0 +1,51 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package argpos
+
+import (
+	"context"
+)
+
+type Options struct{ Retries int }
+
+func do(opts Options, ctx context.Context) error { return nil }
+
+func send(from, to string, ctx context.Context, body []byte) {}
+
+func second(opts Options) error {
+	return do(opts, context.TODO()) // want "Plumb context"
+}
+
+func third() {
+	send("a", "b", context.TODO(), nil) // want "Plumb context"
+}
+
+func multiline() {
+	send(
+		"a",
+		"b",
+		context.TODO(), // want "Plumb context"
+		nil,
+	)
+}
+
+// record gains a context, and the call to it also passes one as a later argument.
+func record(key string, value interface{}) {
+	do(Options{}, context.TODO()) // want "Plumb context"
+}
+
+func caller() {
+	record("ctx", context.TODO()) // want "Plumb context"
+}
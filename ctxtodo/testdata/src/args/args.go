@@ -0,0 +1,38 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package args
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+func nested(name string) string {
+	return strings.ToUpper(fmt.Sprint(name, context.TODO())) // want "Plumb context"
+}
+
+func variadic(name string) {
+	fmt.Println("name:", name, context.TODO()) // want "Plumb context"
+}
+
+func provided(r *http.Request) {
+	fmt.Println(r.URL, strings.TrimSpace(fmt.Sprint(context.TODO()))) // want "Plumb context"
+}
+
+func caller() {
+	variadic(nested("foo"))
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assertions
+
+import (
+	"context"
+	"fmt"
+)
+
+type valueContext interface {
+	context.Context
+	Value(key interface{}) interface{}
+}
+
+func asserted() bool {
+	_, ok := interface{}(context.TODO()).(context.Context) // want "Plumb context"
+	return ok
+}
+
+func assertedDirectly() {
+	_ = context.TODO().(valueContext) // want "Plumb context"
+}
+
+func assertedCtx() {
+	vctx := context.TODO().(valueContext) // want "Plumb context"
+	fmt.Println(vctx.Value("key"))
+}
+
+func typeSwitch() {
+	switch v := context.TODO().(type) { // want "Plumb context"
+	case fmt.Stringer:
+		fmt.Println(v.String())
+	}
+}
+
+func typeSwitchInit() {
+	switch ctx := context.TODO(); ctx.(type) { // want "Plumb context"
+	case fmt.Stringer:
+	}
+}
@@ -15,9 +15,24 @@
 package basic
 
 import (
+	"context"
 	"testing"
 )
 
 func TestA(t *testing.T) {
 	a()
 }
+
+func BenchmarkA(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		a()
+	}
+}
+
+func TestB(t *testing.T) {
+	ctx := context.TODO() // want "Plumb context"
+	a()
+	check(ctx)
+}
+
+func check(ctx context.Context) {}
@@ -0,0 +1,62 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blocks
+
+import (
+	"context"
+	"net/http"
+)
+
+func inBlock() {
+	{
+		reqCtx := background()
+		use(context.TODO()) // want "Plumb context"
+	}
+}
+
+func afterBlock() {
+	{
+		reqCtx := background()
+		use(reqCtx)
+	}
+	use(context.TODO()) // want "Plumb context"
+}
+
+func labeledLoop(items []string) {
+outer:
+	for _, item := range items {
+		if item == "" {
+			continue outer
+		}
+		use(context.TODO()) // want "Plumb context"
+	}
+}
+
+func labeledBlock(r *http.Request) {
+	goto done
+done:
+	{
+		use(context.TODO()) // want "Plumb context"
+	}
+}
+
+func caller() {
+	afterBlock()
+	labeledLoop(nil)
+}
+
+func background() context.Context { return context.Background() }
+
+func use(ctx context.Context) {}
@@ -0,0 +1,31 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bodyless
+
+import "context"
+
+// sum is implemented in assembly, so it has no body.
+func sum(b []byte) uint32
+
+func checksum(b []byte) uint32 {
+	use(context.TODO()) // want "Plumb context"
+	return sum(b)
+}
+
+func verify(b []byte, want uint32) bool {
+	return checksum(b) == want
+}
+
+func use(ctx context.Context) {}
@@ -0,0 +1,5 @@
+#include "textflag.h"
+
+// func sum(b []byte) uint32
+TEXT ·sum(SB), NOSPLIT, $0
+	RET
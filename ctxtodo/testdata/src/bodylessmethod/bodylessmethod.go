@@ -0,0 +1,39 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bodylessmethod
+
+import "context"
+
+// Hasher is implemented by fast (without a body, which go/types accepts but the compiler
+// doesn't support for methods) and slow.
+type Hasher interface {
+	Sum(b []byte) uint32
+}
+
+type fast struct{}
+
+func (fast) Sum(b []byte) uint32 // want "Not adding context to Sum, it has no body"
+
+type slow struct{}
+
+// Sum can't gain a ctx parameter, since fast.Sum can't.  The first context.TODO() finds
+// that out after marking slow.Sum and Hasher.Sum, which the second can't take to mean they have one.
+func (slow) Sum(b []byte) uint32 {
+	use(context.TODO()) // want "Plumb context"
+	use(context.TODO()) // want "Plumb context"
+	return 0
+}
+
+func use(ctx context.Context) {}
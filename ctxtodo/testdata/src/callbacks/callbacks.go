@@ -0,0 +1,42 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package callbacks
+
+import (
+	"context"
+	"time"
+)
+
+func refresh() {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func start() {
+	refresh()
+	time.AfterFunc(time.Minute, refresh) // want "Not adding context to refresh, its signature is fixed by this use"
+}
+
+type cache struct{}
+
+func (c *cache) evict() {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func (c *cache) schedule() {
+	c.evict()
+	defer time.AfterFunc(time.Minute, c.evict).Stop() // want "Not adding context to evict, its signature is fixed by this use"
+}
+
+func check(ctx context.Context) {}
@@ -0,0 +1,45 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cancel
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// The context.TODO() in these assignments is replaced on its own, so that cancel is kept.
+
+func gained() {
+	ctx, cancel := context.WithCancel(context.TODO()) // want "Plumb context"
+	defer cancel()
+	use(ctx)
+}
+
+func nested(retry bool) {
+	if retry {
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Second) // want "Plumb context"
+		defer cancel()
+		use(ctx)
+	}
+}
+
+func provided(r *http.Request) {
+	ctx, cancel := context.WithCancel(context.TODO()) // want "Plumb context"
+	defer cancel()
+	use(ctx)
+}
+
+func use(ctx context.Context) {}
@@ -0,0 +1,33 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package b
+
+import "chain/c"
+
+func Load(keys ...string) []string { // want Load:"NeedsContext"
+	var values []string
+	for _, key := range keys {
+		values = append(values, c.Fetch(key)) // want "Continue plumbing context"
+	}
+	return values
+}
+
+func first(key string) string {
+	return c.Fetch(key) // want "Continue plumbing context"
+}
+
+func Lookup(key string) string { // want Lookup:"NeedsContext"
+	return first(key)
+}
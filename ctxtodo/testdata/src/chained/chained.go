@@ -0,0 +1,41 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chained
+
+import (
+	"context"
+	"net/http"
+)
+
+type key struct{}
+
+func value() interface{} {
+	v := context.TODO().Value(key{}) // want "Plumb context"
+	return v
+}
+
+func provided(r *http.Request) error {
+	return context.TODO().Err() // want "Plumb context"
+}
+
+func deadline() bool {
+	ctx := context.TODO() // want "Plumb context"
+	_, ok := ctx.Deadline()
+	return ok
+}
+
+func done() <-chan struct{} {
+	return context.WithValue(context.TODO(), key{}, 1).Done() // want "Plumb context"
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package channels
+
+import (
+	"context"
+	"net/http"
+)
+
+func send(ch chan<- context.Context) {
+	ch <- context.TODO() // want "Plumb context"
+}
+
+func selectSend(r *http.Request, ch chan<- context.Context, done <-chan struct{}) {
+	select {
+	case ch <- context.TODO(): // want "Plumb context"
+	case <-done:
+	}
+}
+
+func relay(in <-chan context.Context, out chan<- context.Context) {
+	select {
+	case out <- context.TODO(): // want "Plumb context"
+	case ctx := <-in:
+		out <- context.TODO() // want "Plumb context"
+		_ = ctx
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package complit
+
+import (
+	"context"
+	"net/http"
+)
+
+type Request struct {
+	Ctx  context.Context
+	Name string
+}
+
+func keyed(name string) Request {
+	return Request{Ctx: context.TODO(), Name: name} // want "Plumb context"
+}
+
+func unkeyed(name string) *Request {
+	req := &Request{context.TODO(), name} // want "Plumb context"
+	return req
+}
+
+func provided(r *http.Request) Request {
+	return Request{
+		Ctx:  context.TODO(), // want "Plumb context"
+		Name: r.URL.Path,
+	}
+}
+
+func caller() {
+	keyed("a")
+	unkeyed("b")
+}
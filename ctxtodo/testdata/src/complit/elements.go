@@ -0,0 +1,45 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package complit
+
+import (
+	"context"
+	"net/http"
+)
+
+func handlers() []Request {
+	return []Request{
+		{
+			Ctx:  context.TODO(), // want "Plumb context"
+			Name: "first",
+		},
+		{
+			Ctx:  context.TODO(), // want "Plumb context"
+			Name: "second",
+		},
+	}
+}
+
+func contexts(r *http.Request) map[string]context.Context {
+	return map[string]context.Context{
+		"request": context.TODO(), // want "Plumb context"
+	}
+}
+
+func pointers() map[string]*Request {
+	return map[string]*Request{
+		"k": {context.TODO(), "v"}, // want "Plumb context"
+	}
+}
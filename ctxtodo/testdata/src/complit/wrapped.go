@@ -0,0 +1,50 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package complit
+
+import (
+	"context"
+	"net/http"
+)
+
+type Config struct {
+	Handler http.Handler
+	Name    string
+}
+
+func wrap(ctx context.Context, h http.Handler) http.Handler {
+	return h
+}
+
+// logged doesn't have a context until it is plumbed, like the functions calling it.
+func logged(h http.Handler) http.Handler {
+	return wrap(context.TODO(), h) // want "Plumb context"
+}
+
+func newConfig(h http.Handler) Config {
+	return Config{Handler: wrap(context.TODO(), h), Name: "wrapped"} // want "Plumb context"
+}
+
+func newConfigs(h http.Handler) []*Config {
+	return []*Config{
+		{Handler: logged(h), Name: "logged"},
+		{Handler: wrap(context.TODO(), logged(h)), Name: "both"}, // want "Plumb context"
+	}
+}
+
+func setup() {
+	newConfig(nil)
+	newConfigs(http.NotFoundHandler())
+}
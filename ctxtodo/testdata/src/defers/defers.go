@@ -0,0 +1,72 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package defers
+
+import (
+	"context"
+	"net/http"
+)
+
+func work(ctx context.Context) {}
+
+func immediate() {
+	sub, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer work(context.TODO()) // want "Plumb context"
+	work(sub)
+}
+
+func immediateBeforeDecl() {
+	defer work(context.TODO()) // want "Plumb context"
+	sub, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	work(sub)
+}
+
+func closure() {
+	sub, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		work(context.TODO()) // want "Plumb context"
+	}()
+	work(sub)
+}
+
+func closureWithParam(r *http.Request) {
+	sub, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	defer func() {
+		work(context.TODO()) // want "Plumb context"
+	}()
+	work(sub)
+}
+
+func closureLocal() {
+	defer func() {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		work(context.TODO()) // want "Plumb context"
+	}()
+}
+
+func closureArg() {
+	sub, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer run(func() {
+		work(context.TODO()) // want "Plumb context"
+	})
+	work(sub)
+}
+
+func run(f func()) { f() }
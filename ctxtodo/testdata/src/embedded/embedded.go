@@ -0,0 +1,54 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedded
+
+import "context"
+
+type State struct {
+	context.Context
+	name string
+}
+
+func h(s State) {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func hp(s *State) {
+	check(context.TODO()) // want "Plumb context"
+}
+
+type doner struct{}
+
+func (doner) Done() <-chan struct{} { return nil }
+
+// ambiguous doesn't implement context.Context, because Done is ambiguous.
+type ambiguous struct {
+	context.Context
+	doner
+}
+
+func ha(a ambiguous) {
+	check(context.TODO()) // want "Plumb context"
+}
+
+type wrapped struct {
+	ctx context.Context
+}
+
+func hw(w wrapped) {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func check(ctx context.Context) {}
@@ -0,0 +1,66 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedding
+
+import "context"
+
+type UnimplementedServer struct{}
+
+func (UnimplementedServer) ping() {
+	check(context.TODO()) // want "Plumb context"
+}
+
+type server struct {
+	UnimplementedServer
+}
+
+func (s server) serve() {
+	s.ping()
+}
+
+type cache struct{}
+
+func (c *cache) load() {
+	check(context.TODO()) // want "Plumb context"
+}
+
+type service struct {
+	*cache
+	name string
+}
+
+func (s *service) start() {
+	s.load()
+}
+
+type getter interface {
+	get()
+}
+
+type impl struct{}
+
+func (impl) get() {
+	check(context.TODO()) // want "Plumb context"
+}
+
+type wrapper struct {
+	getter
+}
+
+func use(w wrapper) {
+	w.get()
+}
+
+func check(ctx context.Context) {}
@@ -0,0 +1,47 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errgroups
+
+import (
+	"context"
+
+	"errgroups/errgroup"
+)
+
+// fetchAll uses the group's context rather than its parent, so that the
+// other fetches are canceled when one fails.
+func fetchAll(parent context.Context, urls []string) error {
+	g, ctx := errgroup.WithContext(parent)
+	for _, url := range urls {
+		url := url
+		g.Go(func() error {
+			return fetch(url)
+		})
+	}
+	return g.Wait()
+}
+
+func fetchTwo(a, b string) error {
+	g, ctx := errgroup.WithContext(context.Background())
+	g.Go(func() error { return fetch(a) })
+	g.Go(func() error { return fetch(b) })
+	return g.Wait()
+}
+
+func fetch(url string) error {
+	return get(context.TODO(), url) // want "Plumb context"
+}
+
+func get(ctx context.Context, url string) error { return nil }
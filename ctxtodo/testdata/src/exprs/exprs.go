@@ -0,0 +1,40 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exprs
+
+import "context"
+
+func compare() bool {
+	if context.TODO() != nil { // want "Plumb context"
+		return true
+	}
+	return nil == context.TODO() // want "Plumb context"
+}
+
+func not() bool {
+	return !isDone(context.TODO()) // want "Plumb context"
+}
+
+func logical(ready bool) bool {
+	return ready && !isDone(context.TODO()) || context.TODO() == nil // want "Plumb context" "Plumb context"
+}
+
+func paren() bool {
+	return !(isDone(context.TODO())) // want "Plumb context"
+}
+
+func isDone(ctx context.Context) bool {
+	return ctx.Err() != nil
+}
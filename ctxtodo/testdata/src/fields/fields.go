@@ -0,0 +1,53 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fields
+
+import "context"
+
+type client struct {
+	addr string
+}
+
+func (c *client) Do(req string) error { // want Do:"NeedsContext"
+	return send(context.TODO(), c.addr, req) // want "Plumb context"
+}
+
+type service struct {
+	client *client
+	peers  []*client
+	byName map[string]*client
+}
+
+func (s *service) call(req string) error {
+	return s.client.Do(req)
+}
+
+func (s *service) broadcast(req string) {
+	for _, peer := range s.peers {
+		_ = peer.Do(req)
+	}
+	_ = s.peers[0].Do(req)
+	_ = s.byName["primary"].Do(req)
+}
+
+type server struct {
+	svc service
+}
+
+func (srv server) handle() error {
+	return srv.svc.client.Do("ping")
+}
+
+func send(ctx context.Context, addr, req string) error { return nil }
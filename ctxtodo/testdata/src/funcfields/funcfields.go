@@ -0,0 +1,40 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package funcfields
+
+import (
+	"context"
+)
+
+type service struct {
+	do   func(context.Context) error
+	hook func()
+}
+
+// The signatures of the function fields can't change, so only the callers of run gain a ctx parameter.
+func (s *service) run() error {
+	s.hook()
+	return s.do(context.TODO()) // want "Plumb context"
+}
+
+func (s *service) runLocal() error {
+	do := s.do
+	return do(context.TODO()) // want "Plumb context"
+}
+
+func start(s *service) {
+	s.run()
+	s.runLocal()
+}
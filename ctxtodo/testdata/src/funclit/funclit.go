@@ -0,0 +1,40 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package funclit
+
+import "context"
+
+var value = func() int {
+	check(context.TODO()) // want "Plumb context"
+	return 0
+}()
+
+var handler = func(name string) {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func serve() {
+	handler("index")
+}
+
+var replaced = func() {
+	check(context.TODO()) // want "Package-level context.TODO\\(\\) has no context to plumb"
+}
+
+func replace() {
+	replaced = func() {}
+}
+
+func check(ctx context.Context) {}
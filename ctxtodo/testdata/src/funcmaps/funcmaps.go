@@ -0,0 +1,61 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package funcmaps
+
+import "context"
+
+// The signatures of the handlers are fixed by the type of the map (or slice), so they can't gain a
+// ctx parameter, and use context.Background() instead.
+var handlers = map[string]func(){
+	"inline": func() {
+		use(context.TODO()) // want "Package-level context.TODO\\(\\) has no context to plumb"
+	},
+	"named": handleNamed, // want "Not adding context to handleNamed, its signature is fixed by this use"
+}
+
+func handleNamed() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func dispatch(name string) {
+	handlers[name]()
+}
+
+var steps = []func(){
+	stepOne, // want "Not adding context to stepOne, its signature is fixed by this use"
+}
+
+func stepOne() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func runSteps() {
+	for _, step := range steps {
+		step()
+	}
+	steps[0]()
+}
+
+// The handlers in a local map can use the context of the function declaring them.
+func serve(name string) {
+	ops := map[string]func(){
+		"inline": func() {
+			use(context.TODO()) // want "Plumb context"
+		},
+	}
+	ops[name]()
+}
+
+func use(ctx context.Context) {}
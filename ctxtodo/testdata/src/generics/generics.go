@@ -0,0 +1,52 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generics
+
+import (
+	"context"
+)
+
+func mapOf[T, U any](xs []T, f func(T) U) []U {
+	_ = context.TODO() // want "Plumb context"
+	var out []U
+	for _, x := range xs {
+		out = append(out, f(x))
+	}
+	return out
+}
+
+type list[T any] struct {
+	items []T
+}
+
+func (l *list[T]) push(v T) {
+	_ = context.TODO() // want "Plumb context"
+	l.items = append(l.items, v)
+}
+
+func caller() {
+	var l list[string]
+	for _, s := range mapOf([]int{1, 2}, func(int) string { return "" }) {
+		l.push(s)
+	}
+}
+
+type requester interface {
+	Context() context.Context
+}
+
+func handle[R requester](r R) {
+	caller()
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goroutines
+
+import (
+	"context"
+	"net/http"
+)
+
+func work(ctx context.Context) {}
+
+func serve(r *http.Request) {
+	go work(context.TODO())    // want "Plumb context"
+	defer work(context.TODO()) // want "Plumb context"
+}
+
+func handleAll(reqs []*http.Request) {
+	for _, req := range reqs {
+		go func() {
+			work(context.TODO()) // want "Plumb context"
+		}()
+		_ = req
+	}
+}
+
+func cancelled() {
+	sub, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	work(sub)
+
+	defer work(context.TODO()) // want "Plumb context"
+}
+
+func inside() {
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		work(context.TODO()) // want "Plumb context"
+	}()
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+)
+
+// Store looks up values.
+type Store interface {
+	Get(key string) string // want Get:"NeedsContext"
+}
+
+type memStore struct{ values map[string]string }
+
+func (m *memStore) Get(key string) string { // want Get:"NeedsContext"
+	check(context.TODO()) // want "Plumb context"
+	return m.values[key]
+}
+
+type nopStore struct{}
+
+func (nopStore) Get(key string) string { // want Get:"NeedsContext"
+	return ""
+}
+
+func lookup(s Store) string {
+	return s.Get("key")
+}
+
+func check(ctx context.Context) {}
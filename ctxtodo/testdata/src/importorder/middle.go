@@ -0,0 +1,32 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importorder
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"example.com/metrics"
+)
+
+// render's import of context goes between bytes and fmt.
+func render(name string) string {
+	record("middle")
+	var buf bytes.Buffer
+	fmt.Fprint(&buf, strings.ToUpper(name))
+	metrics.Count("render", 1)
+	return buf.String()
+}
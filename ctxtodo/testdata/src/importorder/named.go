@@ -0,0 +1,31 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package importorder
+
+import (
+	"bufio"
+	// The metrics package is imported as m.
+	m "example.com/metrics"
+	"io"
+	str "strings"
+)
+
+// read's imports aren't grouped, so context goes in sorted order among them.
+func read(r io.Reader) error {
+	record("named")
+	m.Count(str.ToLower("read"), 1)
+	_ = bufio.NewReader(r)
+	return nil
+}
@@ -0,0 +1,28 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imports
+
+import (
+	"strings"
+)
+
+// The line directive makes the positions below refer to another file (like the
+// output of goyacc does), but the edits still belong in this one.
+
+//line grammar.y:10
+func parse(s string) string {
+	fetch()
+	return strings.TrimSpace(s)
+}
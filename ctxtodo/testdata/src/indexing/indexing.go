@@ -0,0 +1,33 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexing
+
+import "context"
+
+var cache = map[context.Context]string{}
+
+func cached() string {
+	return cache[context.TODO()] // want "Plumb context"
+}
+
+func shard(shards []string) string {
+	return shards[hash(context.TODO())] // want "Plumb context"
+}
+
+func store(value string) {
+	cache[context.TODO()] = value // want "Plumb context"
+}
+
+func hash(ctx context.Context) int { return 0 }
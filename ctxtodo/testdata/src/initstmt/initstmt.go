@@ -0,0 +1,51 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package initstmt
+
+import (
+	"context"
+	"net/http"
+)
+
+func ifInit() error {
+	if ctx := context.TODO(); ctx.Err() != nil { // want "Plumb context"
+		return ctx.Err()
+	}
+	return nil
+}
+
+func switchInit() {
+	switch ctx := context.TODO(); ctx.Err() { // want "Plumb context"
+	case nil:
+	}
+}
+
+func switchNoTag() {
+	switch ctx := context.TODO(); { // want "Plumb context"
+	case ctx.Err() == nil:
+	}
+}
+
+func provided(r *http.Request) {
+	if ctx := context.TODO(); ctx.Err() != nil { // want "Plumb context"
+		return
+	}
+}
+
+func hasParam(ctx context.Context) {
+	if ctx := context.TODO(); ctx.Err() != nil { // want "Plumb context"
+		return
+	}
+}
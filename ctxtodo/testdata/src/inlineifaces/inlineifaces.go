@@ -0,0 +1,61 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inlineifaces
+
+import (
+	"context"
+	"io"
+)
+
+type conn struct{}
+
+func (conn) Flush(ctx context.Context) error { return nil }
+
+// Methods of inline interfaces are never given a ctx parameter, since the
+// interfaces don't belong to any declaration that plumber could change.
+
+func closeVar() error {
+	var db interface{ Flush(context.Context) error } = conn{}
+	return db.Flush(context.TODO()) // want "Plumb context"
+}
+
+func closeParam(c interface {
+	io.Closer
+	Flush(context.Context) error
+}) error {
+	if err := c.Flush(context.TODO()); err != nil { // want "Plumb context"
+		return err
+	}
+	return c.Close()
+}
+
+func closeAsserted(v interface{}) error {
+	if c, ok := v.(interface{ Flush(context.Context) error }); ok {
+		return c.Flush(context.TODO()) // want "Plumb context"
+	}
+	return v.(interface{ Close() error }).Close()
+}
+
+func closeError(err interface{ error }) string {
+	return err.Error()
+}
+
+type holder struct {
+	closer interface{ Close() error }
+}
+
+func (h holder) close() error {
+	return h.closer.Close()
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package instantiation
+
+import (
+	"context"
+)
+
+func process[T any](ctx context.Context, v T) T {
+	return v
+}
+
+func pair[K comparable, V any](k K, v V) map[K]V {
+	_ = context.TODO() // want "Plumb context"
+	return map[K]V{k: v}
+}
+
+func explicit() {
+	process[int](context.TODO(), 1) // want "Plumb context"
+}
+
+func caller() {
+	explicit()
+	_ = pair[string, int]("a", 1)
+	_ = pair("b", 2)
+}
+
+var handlers = []func(context.Context){
+	func(ctx context.Context) {},
+}
+
+func indexed() {
+	handlers[0](context.TODO()) // want "Plumb context"
+}
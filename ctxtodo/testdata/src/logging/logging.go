@@ -0,0 +1,44 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+)
+
+func describe() string {
+	return fmt.Sprintf("request context: %v", context.TODO()) // want "Plumb context"
+}
+
+func wrap(err error) error {
+	return fmt.Errorf("in %v: %w", context.TODO(), err) // want "Plumb context"
+}
+
+func trace() {
+	log.Printf("tracing %v", context.TODO()) // want "Plumb context"
+}
+
+func record(msg string) {
+	slog.InfoContext(context.TODO(), msg, "source", "logging") // want "Plumb context"
+}
+
+func handle() error {
+	record(describe())
+	trace()
+	return wrap(nil)
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loopclosures
+
+import (
+	"context"
+	"net/http"
+)
+
+type DB struct{}
+
+func (db *DB) Close(ctx context.Context) error { return nil }
+
+func closeAll(ctx context.Context, dbs []*DB) {
+	for _, db := range dbs {
+		go func() {
+			db.Close(context.TODO()) // want "Plumb context"
+		}()
+	}
+}
+
+func closePerRequest(reqs []*http.Request, dbs []*DB) {
+	for i, r := range reqs {
+		go func() {
+			dbs[i].Close(context.TODO()) // want "Plumb context"
+		}()
+		_ = r
+	}
+}
+
+func perIteration(parent context.Context, dbs []*DB) {
+	for _, db := range dbs {
+		ctx, cancel := context.WithCancel(parent)
+		go func() {
+			db.Close(context.TODO()) // want "Plumb context"
+		}()
+		cancel()
+		_ = ctx
+	}
+}
+
+func stored(reqs []*http.Request, db *DB) []func() {
+	var cleanups []func()
+	for _, r := range reqs {
+		cleanups = append(cleanups, func() {
+			db.Close(context.TODO()) // want "Plumb context"
+		})
+		_ = r
+	}
+	return cleanups
+}
+
+func storedWithOuter(base *http.Request, reqs []*http.Request, db *DB) []func() {
+	var cleanups []func()
+	for _, r := range reqs {
+		cleanups = append(cleanups, func() {
+			db.Close(context.TODO()) // want "Plumb context"
+		})
+		_ = r
+	}
+	return cleanups
+}
+
+func immediate(reqs []*http.Request, db *DB) {
+	for _, r := range reqs {
+		func() {
+			db.Close(context.TODO()) // want "Plumb context"
+		}()
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loops
+
+import (
+	"context"
+	"net/http"
+)
+
+func check(ctx context.Context) {}
+
+func values(ctx context.Context) []int { return nil }
+
+func done(ctx context.Context) bool { return true }
+
+func forInit() {
+	for ctx := context.TODO(); ctx.Err() == nil; { // want "Plumb context"
+		break
+	}
+}
+
+func forInitPost(n int) {
+	for ctx := context.TODO(); ctx.Err() == nil; n-- { // want "Plumb context"
+		check(ctx)
+	}
+}
+
+func forInitProvided(r *http.Request) {
+	for ctx := context.TODO(); ctx.Err() == nil; { // want "Plumb context"
+		break
+	}
+}
+
+func forInitShadowed() {
+	for ctx := context.TODO(); ctx.Err() == nil; { // want "Plumb context"
+		ctx := context.Background()
+		check(ctx)
+	}
+}
+
+func forCond(r *http.Request) {
+	for !done(context.TODO()) { // want "Plumb context"
+		ctx := r.Context()
+		check(ctx)
+	}
+}
+
+func rangeExpr() {
+	for range values(context.TODO()) { // want "Plumb context"
+		ctx := context.Background()
+		check(ctx)
+	}
+}
+
+func rangeBodyRequest(reqs []*http.Request) {
+	for _, v := range values(context.TODO()) { // want "Plumb context"
+		r := reqs[v]
+		check(r.Context())
+	}
+}
+
+func contexts(ctx context.Context) []context.Context { return nil }
+
+func rangeContexts() {
+	for _, ctx := range contexts(context.TODO()) { // want "Plumb context"
+		check(ctx)
+	}
+}
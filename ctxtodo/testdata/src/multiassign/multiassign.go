@@ -0,0 +1,66 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multiassign
+
+import (
+	"context"
+)
+
+func setup() (context.Context, error) {
+	return context.Background(), nil
+}
+
+func fetch() {
+	check(context.TODO()) // want "Plumb context"
+}
+
+func caller() error {
+	ctx, err := setup()
+	if err != nil {
+		return err
+	}
+	fetch()
+	return nil
+}
+
+func ranged(ctxs []context.Context) {
+	for _, c := range ctxs {
+		fetch()
+	}
+}
+
+func ignored() error {
+	_, err := setup()
+	fetch()
+	return err
+}
+
+func setupFrom(n int) (context.Context, error) {
+	return context.Background(), nil
+}
+
+func value() int {
+	check(context.TODO()) // want "Plumb context"
+	return 0
+}
+
+// within can't use the ctx from the assignment that the call is part of.
+func within() error {
+	ctx, err := setupFrom(value())
+	check(ctx)
+	return err
+}
+
+func check(ctx context.Context) {}
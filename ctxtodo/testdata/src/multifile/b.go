@@ -0,0 +1,32 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multifile
+
+import (
+	"fmt"
+)
+
+// store is declared here, but its methods are in store_methods.go.
+type store struct {
+	saved []string
+}
+
+func newStore() *store {
+	return &store{}
+}
+
+func (s *store) String() string {
+	return fmt.Sprint(s.saved)
+}
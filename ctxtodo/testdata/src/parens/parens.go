@@ -0,0 +1,41 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parens
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+func wait() {
+	<-(context.TODO()).Done() // want "Plumb context"
+}
+
+func deadline() (time.Time, bool) {
+	return (context.TODO()).Deadline() // want "Plumb context"
+}
+
+func value(key string) interface{} {
+	return ((context.TODO())).Value(key) // want "Plumb context"
+}
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	use((context.TODO())) // want "Plumb context"
+
+	<-(context.TODO()).Done() // want "Plumb context"
+}
+
+func use(ctx context.Context) {}
@@ -0,0 +1,40 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prefer
+
+import (
+	"context"
+	"net/http"
+)
+
+func a() {
+	_ = context.TODO() // want "Plumb context"
+}
+
+func both(r *http.Request, ctx context.Context) {
+	a()
+}
+
+func local(r *http.Request) {
+	ctx := context.WithValue(r.Context(), "key", "value")
+	a()
+	_ = ctx
+}
+
+func closure(ctx context.Context) {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		a()
+	})
+}
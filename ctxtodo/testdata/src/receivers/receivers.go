@@ -0,0 +1,99 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package receivers
+
+import "context"
+
+// A request carries the context it was received with.
+type request struct {
+	ctx context.Context
+}
+
+func (r *request) Context() context.Context { return r.ctx }
+
+func (r *request) direct() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func (r *request) indirect() {
+	fetch()
+}
+
+func (r *request) param(ctx context.Context) {
+	fetch()
+}
+
+func (r *request) async() {
+	go func() {
+		use(context.TODO()) // want "Plumb context"
+	}()
+}
+
+func (r *request) later() {
+	go fetch()
+}
+
+// A handler gets its context from the request it is handling.
+type handler struct {
+	req *request
+}
+
+func (h handler) Context() context.Context { return h.req.ctx }
+
+func (h handler) serve() {
+	fetch()
+}
+
+func (h *handler) serveP() {
+	fetch()
+}
+
+func (handler) anonymous() { // want "Name this receiver if you want plumber to use it"
+	go func() {
+		use(context.TODO()) // want "Plumb context"
+	}()
+}
+
+// A session embeds its request, so it has a promoted Context method.
+type session struct {
+	*request
+	user string
+}
+
+func (s session) load() {
+	fetch()
+}
+
+// A stateful value embeds a context directly, so it is a context.
+type stateful struct {
+	context.Context
+}
+
+func (s *stateful) run() {
+	fetch()
+}
+
+// A plain receiver has no context to offer.
+type plain struct{}
+
+func (plain) work() {
+	fetch()
+}
+
+func fetch() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func use(ctx context.Context) {}
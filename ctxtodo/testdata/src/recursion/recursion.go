@@ -0,0 +1,61 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recursion
+
+import (
+	"context"
+)
+
+func countdown(n int) {
+	if n == 0 {
+		return
+	}
+	n--
+	countdown(n)
+	use(context.TODO()) // want "Plumb context"
+}
+
+// walk only reaches the context.TODO() through visit.
+func walk(depth int) {
+	visit()
+	if depth--; depth >= 0 {
+		walk(depth)
+	}
+}
+
+func visit() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+type tree struct {
+	children []*tree
+}
+
+func (t *tree) Size() int { // want Size:"NeedsContext"
+	n := 1
+	for _, c := range t.children {
+		n += c.Size()
+	}
+	use(context.TODO()) // want "Plumb context"
+	return n
+}
+
+func start() {
+	countdown(3)
+	walk(2)
+	_ = (&tree{}).Size()
+}
+
+func use(ctx context.Context) {}
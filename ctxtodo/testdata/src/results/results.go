@@ -0,0 +1,45 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"context"
+	"net/http"
+)
+
+type key struct{}
+
+func open(name string) (ctx context.Context, err error) {
+	ctx = context.WithValue(context.Background(), key{}, name)
+	err = check(context.TODO(), name) // want "Plumb context"
+	return ctx, err
+}
+
+func build(url string) (req *http.Request, err error) {
+	req, err = http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return req, check(context.TODO(), url) // want "Plumb context"
+}
+
+// early can't use its result before it is assigned, so it gains a parameter.
+func early() (out context.Context, err error) {
+	err = check(context.TODO(), "early") // want "Plumb context"
+	out = context.Background()
+	return out, err
+}
+
+func check(ctx context.Context, name string) error { return nil }
@@ -0,0 +1,44 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package returns
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+func newContext() context.Context {
+	return context.TODO() // want "newContext only returns context.TODO\\(\\), its callers should use their own context directly"
+}
+
+func requestContext(r *http.Request) context.Context {
+	return context.TODO() // want "Plumb context"
+}
+
+func timeoutContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.TODO(), time.Second) // want "Plumb context"
+}
+
+func loggedContext() context.Context {
+	println("creating context")
+	return context.TODO() // want "Plumb context"
+}
+
+func use() {
+	check(newContext())
+}
+
+func check(ctx context.Context) {}
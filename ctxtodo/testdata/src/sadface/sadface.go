@@ -0,0 +1,62 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sadface
+
+import (
+	"context"
+)
+
+func a(ctx bool) { // want `Non-context ctx parameter \(of type bool\) has to be renamed for a to gain a context`
+	_ = context.TODO() // want "Plumb context"
+}
+
+func b() {
+	ctx := 42 // want "Not adding context to b, it declares a non-context ctx"
+	c()
+	_ = ctx
+}
+
+func c() {
+	_ = context.TODO() // want "Plumb context"
+}
+
+var _ = context.Background() // don't drop the context import
+
+func x(ctx int) { // want `Non-context ctx parameter \(of type int\) has to be renamed for x to gain a context`
+	_ = ctx + 1
+	func() { _ = ctx * 2 }()
+	y()
+}
+
+func y() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func use(ctx context.Context) {}
+
+func f() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func g() {
+	ctx := 1 // want "Not adding context to g, it declares a non-context ctx"
+	f()
+	_ = ctx
+}
+
+func h() {
+	use(context.TODO()) // want "Plumb context"
+	f()
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sameline
+
+import (
+	"context"
+	"net/http"
+)
+
+func copyValues() {
+	merge(context.TODO(), context.TODO()) // want "Plumb context" "Plumb context"
+}
+
+// run only gains one ctx parameter (and passes it once) for both of its TODOs.
+func run() {
+	copyValues()
+	merge(context.TODO(), context.TODO()) // want "Plumb context" "Plumb context"
+}
+
+func start(w http.ResponseWriter, r *http.Request) {
+	run()
+}
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	merge(context.TODO(), context.TODO()) // want "Plumb context" "Plumb context"
+}
+
+func merge(dst, src context.Context) {}
@@ -0,0 +1,59 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shadow
+
+import (
+	"context"
+	"net/http"
+)
+
+func a() {
+	_ = context.TODO() // want "Plumb context"
+}
+
+func lookup() (context.Context, bool) {
+	return context.Background(), true
+}
+
+func sibling(ok bool) {
+	if ok {
+		ctx := context.Background()
+		_ = ctx
+	}
+	a()
+}
+
+func nested() {
+	if ctx, ok := lookup(); ok {
+		{
+			a()
+		}
+	}
+}
+
+func later() {
+	a()
+	if ctx, ok := lookup(); ok {
+		_ = ctx
+	}
+}
+
+func shadowedParam(r *http.Request) {
+	for _, r := range []string{"x"} {
+		_ = r
+		a()
+	}
+	a()
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shadowing
+
+import (
+	"context"
+	"fmt"
+)
+
+func a() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+// callsA gains a ctx parameter to pass to a.
+func callsA() {
+	a()
+}
+
+// callsClosure only calls its own a, so it is left alone.
+func callsClosure() {
+	a := func() { fmt.Println("not the package-level a") }
+	a()
+}
+
+// callsParam only calls the a it is given, so it is left alone.
+func callsParam(a func()) {
+	a()
+}
+
+// callsBoth calls the package-level a before shadowing it.
+func callsBoth() {
+	a()
+	{
+		a := func() {}
+		a()
+	}
+}
+
+func use(ctx context.Context) {}
@@ -0,0 +1,58 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testhelpers
+
+import "testing"
+
+// setupServer is a helper, so it uses the context of the test it is given.
+func setupServer(t *testing.T) {
+	t.Helper()
+	load()
+}
+
+// setup works with any test or benchmark.
+func setup(tb testing.TB) string {
+	return load()
+}
+
+// fixture has no way to get a context, so it gains a parameter like any other function.
+func fixture() string {
+	return load()
+}
+
+// Testdata isn't a test, since its name continues in lowercase.
+func Testdata() string { // want Testdata:"NeedsContext"
+	return load()
+}
+
+func TestSetup(t *testing.T) {
+	setupServer(t)
+	_ = setup(t)
+}
+
+func TestFixture(t *testing.T) {
+	_ = fixture()
+	_ = Testdata()
+}
+
+func Test_underscore(t *testing.T) {
+	_ = fixture()
+}
+
+func BenchmarkSetup(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = setup(b)
+	}
+}
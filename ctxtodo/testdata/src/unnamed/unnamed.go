@@ -0,0 +1,49 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unnamed
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// Every parameter needs a name once one of them has one.
+func mixed(http.ResponseWriter, *http.Request, ...string) { // want "Name this param if you want plumber to use it"
+	fetch()
+}
+
+// The usual name is taken, so a number is added.
+func taken(*http.Request) { // want "Name this param if you want plumber to use it"
+	r := "request"
+	fetch()
+	_ = r
+}
+
+// A context is named like any other.
+func direct(context.Context, *testing.T) { // want "Name this param if you want plumber to use it"
+	fetch()
+}
+
+// A blank parameter can't be used, so another provider is found.
+func blank(_ *http.Request, ctx context.Context) {
+	fetch()
+}
+
+func fetch() {
+	use(context.TODO()) // want "Plumb context"
+}
+
+func use(ctx context.Context) {}
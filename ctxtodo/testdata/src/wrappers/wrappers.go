@@ -0,0 +1,59 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wrappers
+
+import (
+	"context"
+	"time"
+)
+
+type key struct{}
+
+func check(ctx context.Context) {}
+
+func withCancel() {
+	ctx, cancel := context.WithCancel(context.TODO()) // want "Plumb context"
+	defer cancel()
+	check(ctx)
+}
+
+func withTimeout(d time.Duration) {
+	ctx, cancel := context.WithTimeout(context.TODO(), d) // want "Plumb context"
+	defer cancel()
+	check(ctx)
+}
+
+func withDeadline(t time.Time) {
+	ctx, cancel := context.WithDeadline(context.TODO(), t) // want "Plumb context"
+	defer cancel()
+	check(ctx)
+}
+
+func withValue() {
+	ctx := context.WithValue(context.TODO(), key{}, "value") // want "Plumb context"
+	check(ctx)
+}
+
+func nested(d time.Duration) {
+	ctx, cancel := context.WithTimeout(context.WithValue(context.TODO(), key{}, "value"), d) // want "Plumb context"
+	defer cancel()
+	check(ctx)
+}
+
+func checkedFirst() {
+	check(context.TODO()) // want "Plumb context"
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	check(ctx)
+}
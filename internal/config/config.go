@@ -0,0 +1,250 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config reads a .plumber.yaml file of flag settings, so that a team
+// can share its plumber configuration in the root of its module.
+//
+// The file holds the flags of the ctxtodo analyzer by name, in a small subset of YAML:
+//
+//	# Comments start with a '#'.
+//	maxdepth: 3
+//	ctxname: ctx
+//	exclude-files:
+//	  - "*.pb.go"
+//	  - "*_gen.go"
+//	context-method-names: [Ctx, RequestContext]
+//
+// Each value is set as if it were given on the command line, and each item of a
+// list is set as if its flag were repeated.  A list given on the command line
+// replaces the one in the file.
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Filename is the name of the config file, in the root of a module.
+const Filename = ".plumber.yaml"
+
+// A setting is a key of the config file and its values.
+type setting struct {
+	line   int
+	key    string
+	values []string
+	block  bool // whether values are the items on the indented lines that follow
+}
+
+// Find returns the path of the config file in the root of the module containing dir
+// (the closest directory with a go.mod file), if there is one.
+func Find(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			path := filepath.Join(dir, Filename)
+			_, err := os.Stat(path)
+			return path, err == nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// A Resetter is a flag.Value that accumulates the values of a repeated flag,
+// which can be cleared with Reset.
+type Resetter interface {
+	flag.Value
+	Reset()
+}
+
+// Load reads the config file at path and sets the flags in fs from it.
+//
+// Flags should be parsed from the command line afterward, so that they take precedence
+// and replace the values from the file.  For that, the Value of each Resetter flag that
+// is set from the file is replaced with one that resets it when it is set again.
+func Load(path string, fs *flag.FlagSet) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	settings, err := parse(data)
+	if err != nil {
+		return fmt.Errorf("%s:%s", path, err)
+	}
+	for _, s := range settings {
+		if fs.Lookup(s.key) == nil {
+			return fmt.Errorf("%s:%d: unknown flag %q", path, s.line, s.key)
+		}
+		for _, v := range s.values {
+			if err := fs.Set(s.key, v); err != nil {
+				return fmt.Errorf("%s:%d: invalid value %q for %s: %s", path, s.line, v, s.key, err)
+			}
+		}
+		if f := fs.Lookup(s.key); isResetter(f.Value) {
+			f.Value = &configured{Resetter: f.Value.(Resetter)}
+		}
+	}
+	return nil
+}
+
+func isResetter(v flag.Value) bool {
+	_, ok := v.(Resetter)
+	return ok
+}
+
+// configured is the Value of a repeated flag that was set from the config file,
+// which is reset the first time that it's set again (from the command line).
+type configured struct {
+	Resetter
+	overridden bool
+}
+
+func (c *configured) String() string {
+	if c.Resetter == nil {
+		return "" // the zero value, for flag.PrintDefaults
+	}
+	return c.Resetter.String()
+}
+
+func (c *configured) Set(value string) error {
+	if !c.overridden {
+		c.Resetter.Reset()
+		c.overridden = true
+	}
+	return c.Resetter.Set(value)
+}
+
+// parse parses the settings in a config file.  Errors are prefixed with their line number.
+func parse(data []byte) ([]setting, error) {
+	var settings []setting
+	seen := map[string]bool{}
+	lines := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; lines.Scan(); n++ {
+		raw := lines.Text()
+		line, err := stripComment(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %s", n, err)
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if indented := strings.TrimLeft(line, " \t"); indented != line {
+			item := strings.TrimSpace(strings.TrimPrefix(indented, "-"))
+			if !strings.HasPrefix(indented, "-") || len(settings) == 0 || !settings[len(settings)-1].block {
+				return nil, fmt.Errorf("%d: unexpected indented line %q", n, strings.TrimSpace(raw))
+			}
+			v, err := unquote(item)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %s", n, err)
+			}
+			last := &settings[len(settings)-1]
+			last.values = append(last.values, v)
+			continue
+		}
+
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("%d: expected \"key: value\", found %q", n, strings.TrimSpace(raw))
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if seen[key] {
+			return nil, fmt.Errorf("%d: duplicate key %q", n, key)
+		}
+		seen[key] = true
+
+		s := setting{line: n, key: key, values: []string{}}
+		switch {
+		case value == "":
+			s.block = true
+		case strings.HasPrefix(value, "["):
+			if !strings.HasSuffix(value, "]") {
+				return nil, fmt.Errorf("%d: unterminated list %q", n, value)
+			}
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = strings.TrimSpace(item); item == "" {
+					continue
+				}
+				v, err := unquote(item)
+				if err != nil {
+					return nil, fmt.Errorf("%d: %s", n, err)
+				}
+				s.values = append(s.values, v)
+			}
+		default:
+			v, err := unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %s", n, err)
+			}
+			s.values = append(s.values, v)
+		}
+		settings = append(settings, s)
+	}
+	return settings, lines.Err()
+}
+
+// stripComment removes a comment (from a '#' at the start of the line or after
+// whitespace, outside of quotes) from line.
+func stripComment(line string) (string, error) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i], nil
+		}
+	}
+	if quote != 0 {
+		return "", fmt.Errorf("unterminated string in %q", strings.TrimSpace(line))
+	}
+	return line, nil
+}
+
+// unquote returns the string value of a scalar, which may be double-quoted (with Go escapes)
+// or single-quoted (with a doubled single quote for each one in the value).
+func unquote(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("invalid string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return s, nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2021 Kyle Lemons
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/plumber/ctxtodo"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []setting
+		wantErr string
+	}{
+		{
+			name:  "scalars",
+			input: "maxdepth: 3\nctxname: \"c\"\nroot-context: 'rootCtx'\n",
+			want: []setting{
+				{line: 1, key: "maxdepth", values: []string{"3"}},
+				{line: 2, key: "ctxname", values: []string{"c"}},
+				{line: 3, key: "root-context", values: []string{"rootCtx"}},
+			},
+		},
+		{
+			name:  "comments",
+			input: "# header\n\nfix-comments-pattern: \"#todo\" # trailing\nctxname: c#d\n",
+			want: []setting{
+				{line: 3, key: "fix-comments-pattern", values: []string{"#todo"}},
+				{line: 4, key: "ctxname", values: []string{"c#d"}},
+			},
+		},
+		{
+			name:  "lists",
+			input: "exclude-files:\n  - a.go\n  # skipped\n  - 'it''s.go'\npackage-allowlist: [x, \"y\"]\nentrypoints: []\n",
+			want: []setting{
+				{line: 1, key: "exclude-files", values: []string{"a.go", "it's.go"}, block: true},
+				{line: 5, key: "package-allowlist", values: []string{"x", "y"}},
+				{line: 6, key: "entrypoints", values: []string{}},
+			},
+		},
+		{
+			name:    "duplicate",
+			input:   "maxdepth: 1\nmaxdepth: 2\n",
+			wantErr: `2: duplicate key "maxdepth"`,
+		},
+		{
+			name:    "missing colon",
+			input:   "maxdepth 1\n",
+			wantErr: `1: expected "key: value"`,
+		},
+		{
+			name:    "item without list",
+			input:   "maxdepth: 1\n  - 2\n",
+			wantErr: "2: unexpected indented line",
+		},
+		{
+			name:    "unterminated string",
+			input:   "ctxname: \"c\n",
+			wantErr: "1: unterminated string",
+		},
+		{
+			name:    "unterminated list",
+			input:   "entrypoints: [main\n",
+			wantErr: "1: unterminated list",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parse([]byte(test.input))
+			if test.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.wantErr) {
+					t.Fatalf("parse(%q) error = %v, want prefix %q", test.input, err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse(%q): %s", test.input, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parse(%q) = %+v, want %+v", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+// TestLoad ensures that the analyzer's flags pick up the values in a config file.
+func TestLoad(t *testing.T) {
+	defer func(orig string) { ctxtodo.ModuleCache = orig }(ctxtodo.ModuleCache)
+	defer func(orig int) { ctxtodo.MaxDepth = orig }(ctxtodo.MaxDepth)
+	defer func(orig string) { ctxtodo.ContextName = orig }(ctxtodo.ContextName)
+	defer func(orig string) { ctxtodo.FixMode = orig }(ctxtodo.FixMode)
+	defer func(orig []string) { ctxtodo.ExcludeFiles = orig }(ctxtodo.ExcludeFiles)
+	defer func(orig []string) { ctxtodo.ContextMethods = orig }(ctxtodo.ContextMethods)
+
+	fs := &ctxtodo.Analyzer.Flags
+	if err := Load(filepath.Join("testdata", "plumber.yaml"), fs); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	// The command line is parsed afterward, so it takes precedence (even for lists).
+	if err := fs.Parse([]string{"-maxdepth=5", "-exclude-files=*.sql.go", "-exclude-files=*.twirp.go"}); err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+
+	if got, want := ctxtodo.ModuleCache, "/tmp/modcache"; got != want {
+		t.Errorf("ModuleCache = %q, want %q", got, want)
+	}
+	if got, want := ctxtodo.MaxDepth, 5; got != want {
+		t.Errorf("MaxDepth = %d, want %d", got, want)
+	}
+	if got, want := ctxtodo.ContextName, "c"; got != want {
+		t.Errorf("ContextName = %q, want %q", got, want)
+	}
+	if got, want := ctxtodo.FixMode, ctxtodo.FixBackground; got != want {
+		t.Errorf("FixMode = %q, want %q", got, want)
+	}
+	if got, want := ctxtodo.ExcludeFiles, []string{"*.sql.go", "*.twirp.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExcludeFiles = %q, want %q", got, want)
+	}
+	if got, want := ctxtodo.ContextMethods, []string{"Ctx", "RequestContext"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ContextMethods = %q, want %q", got, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"unknown flag", "stop: main\n", `1: unknown flag "stop"`},
+		{"invalid value", "\nmaxdepth: deep\n", `2: invalid value "deep" for maxdepth`},
+		{"parse error", "maxdepth\n", "1: expected"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), Filename)
+			if err := os.WriteFile(path, []byte(test.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.Int("maxdepth", 0, "")
+			err := Load(path, fs)
+			if want := path + ":" + test.wantErr; err == nil || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("Load error = %v, want prefix %q", err, want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "internal", "pkg")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, ok := Find(sub); ok {
+		t.Errorf("Find(%q) = %q, want none before the config file exists", sub, path)
+	}
+
+	want := filepath.Join(root, Filename)
+	if err := os.WriteFile(want, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, ok := Find(sub); !ok || path != want {
+		t.Errorf("Find(%q) = %q, %v, want %q", sub, path, ok, want)
+	}
+}